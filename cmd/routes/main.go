@@ -0,0 +1,260 @@
+// Command routes prints the route table built by a router-building function,
+// or the problems of the table reported by mux.Validate.
+//
+// Usage:
+//
+//	routes [table] [--json] <package>.<function>
+//	routes lint [--json] <package>.<function>
+//
+// The function must have the func(*mux.Mux) signature, and its package must not
+// be a main package (e.g. ./api.Routes or github.com/user/app/api.Routes).
+// It is called by a program generated in a temporary directory, and run with the
+// go command in the current directory: the package must belong to the main module
+// of the current directory, or to one of its dependencies.
+//
+// The exit code is 1 if problems are found, and 2 if the function cannot be loaded.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/carlito767/go-stack/clp"
+)
+
+type options struct {
+	JSON     bool `name:"json,j"`
+	Function string
+}
+
+// report is the output of the generated program.
+type report struct {
+	Routes []struct {
+		Method  string `json:"method"`
+		Pattern string `json:"pattern"`
+		Host    string `json:"host,omitempty"`
+		Scheme  string `json:"scheme,omitempty"`
+		Version string `json:"version,omitempty"`
+		Name    string `json:"name,omitempty"`
+	} `json:"routes"`
+	Problems []struct {
+		Kind    string `json:"kind"`
+		Route   string `json:"route,omitempty"`
+		Message string `json:"message"`
+	} `json:"problems"`
+}
+
+// errProblems is returned when problems are found.
+var errProblems = errors.New("problems found")
+
+func main() {
+	os.Exit(command(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli holds the outputs of the commands.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command handles the command of the arguments, and returns the exit code.
+func command(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+	err := clp.HandleCommandsFromArgs(map[string]clp.Handler{
+		"":      c.table,
+		"table": c.table,
+		"lint":  c.lint,
+	}, args)
+	switch {
+	case errors.Is(err, errProblems):
+		return 1
+	case err != nil:
+		fmt.Fprintln(stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
+
+func (c *cli) table(args []string) error {
+	var o options
+	r, err := c.load(&o, args)
+	if err != nil {
+		return err
+	}
+	if o.JSON {
+		return c.output(r.Routes, len(r.Problems))
+	}
+	for _, route := range r.Routes {
+		method := route.Method
+		if method == "" {
+			method = "*"
+		}
+		pattern := route.Host + route.Pattern
+		if route.Scheme != "" {
+			pattern = route.Scheme + "://" + pattern
+		}
+		fmt.Fprintf(c.stdout, "%-7s %-40s %s\n", method, pattern, route.Name)
+	}
+	if len(r.Problems) > 0 {
+		fmt.Fprintf(c.stdout, "\n%d problem(s) found, see: routes lint %s\n", len(r.Problems), o.Function)
+		return errProblems
+	}
+	return nil
+}
+
+func (c *cli) lint(args []string) error {
+	var o options
+	r, err := c.load(&o, args)
+	if err != nil {
+		return err
+	}
+	if o.JSON {
+		return c.output(r.Problems, len(r.Problems))
+	}
+	for _, p := range r.Problems {
+		if p.Route == "" {
+			fmt.Fprintf(c.stdout, "%s: %s\n", p.Kind, p.Message)
+		} else {
+			fmt.Fprintf(c.stdout, "%s: %s: %s\n", p.Kind, p.Route, p.Message)
+		}
+	}
+	if len(r.Problems) > 0 {
+		fmt.Fprintf(c.stdout, "%d problem(s) found\n", len(r.Problems))
+		return errProblems
+	}
+	return nil
+}
+
+// output prints v in JSON.
+func (c *cli) output(v any, problems int) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if problems > 0 {
+		return errProblems
+	}
+	return nil
+}
+
+// load parses the arguments, then generates and runs the program reporting
+// the routes built by the function.
+func (c *cli) load(o *options, args []string) (*report, error) {
+	if err := clp.ParseOptionsFromArgs(o, args); err != nil {
+		return nil, err
+	}
+	i := strings.LastIndex(o.Function, ".")
+	if i <= 0 || i == len(o.Function)-1 || strings.HasSuffix(o.Function[:i], "/") {
+		return nil, fmt.Errorf("invalid function: '%s' (expected: <package>.<function>)", o.Function)
+	}
+	pkg, fn := o.Function[:i], o.Function[i+1:]
+
+	out, err := exec.Command("go", "list", "-f", "{{.ImportPath}}", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("package '%s' not found: %w", pkg, commandError(err))
+	}
+	importPath := strings.TrimSpace(string(out))
+	if strings.Contains(importPath, "\n") {
+		return nil, fmt.Errorf("invalid package: '%s' (several packages)", pkg)
+	}
+
+	tmp, err := os.MkdirTemp("", "routes")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	var src bytes.Buffer
+	if err := program.Execute(&src, map[string]string{"Package": importPath, "Function": fn}); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), src.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+
+	// The output of the function is not mixed with the report.
+	cmd := exec.Command("go", "run", filepath.Join(tmp, "main.go"), filepath.Join(tmp, "report.json"))
+	cmd.Stdout = c.stderr
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cannot load '%s': %w\n%s", o.Function, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	data, err := os.ReadFile(filepath.Join(tmp, "report.json"))
+	if err != nil {
+		return nil, err
+	}
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	return &r, nil
+}
+
+// commandError returns the error of a command, with its standard error output.
+func commandError(err error) error {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+		return fmt.Errorf("%w\n%s", err, bytes.TrimSpace(ee.Stderr))
+	}
+	return err
+}
+
+// program is the program reporting the routes built by the function.
+var program = template.Must(template.New("program").Parse(`// Code generated by cmd/routes. DO NOT EDIT.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/carlito767/go-stack/mux"
+
+	target {{printf "%q" .Package}}
+)
+
+func main() {
+	type route struct {
+		Method  string ` + "`json:\"method\"`" + `
+		Pattern string ` + "`json:\"pattern\"`" + `
+		Host    string ` + "`json:\"host,omitempty\"`" + `
+		Scheme  string ` + "`json:\"scheme,omitempty\"`" + `
+		Version string ` + "`json:\"version,omitempty\"`" + `
+		Name    string ` + "`json:\"name,omitempty\"`" + `
+	}
+	type problem struct {
+		Kind    string ` + "`json:\"kind\"`" + `
+		Route   string ` + "`json:\"route,omitempty\"`" + `
+		Message string ` + "`json:\"message\"`" + `
+	}
+	var report struct {
+		Routes   []route   ` + "`json:\"routes\"`" + `
+		Problems []problem ` + "`json:\"problems\"`" + `
+	}
+	report.Routes, report.Problems = []route{}, []problem{}
+
+	router, problems := mux.Validate(target.{{.Function}})
+	for _, r := range router.Routes() {
+		report.Routes = append(report.Routes, route{r.Method, r.Pattern, r.Host, r.Scheme, r.Version, r.Name})
+	}
+	for _, p := range problems {
+		report.Problems = append(report.Problems, problem{p.Kind.String(), p.Route, p.Message})
+	}
+	data, err := json.Marshal(report)
+	if err == nil {
+		err = os.WriteFile(os.Args[1], data, 0o644)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+`))
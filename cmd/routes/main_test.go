@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	const conflict = "conflicts with 'GET /a/{x}': GET /{y}/b and GET /a/{x} both match some paths"
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string // prefix of the standard output
+		stderr string // substring of the standard error output
+	}{
+		{
+			name: "table",
+			args: []string{"./testdata/api.Routes"},
+			stdout: "GET     /users                                   \n" +
+				"GET     /users/{id}                              user\n" +
+				"POST    api.example.com/users                    \n",
+		},
+		{
+			name:   "table with problems",
+			args:   []string{"table", "./testdata/api.Conflicts"},
+			code:   1,
+			stdout: "GET     /a/{x}                                   \n*       /any                                     \n\n2 problem(s) found, see: routes lint ./testdata/api.Conflicts\n",
+		},
+		{
+			name: "lint",
+			args: []string{"lint", "./testdata/api.Routes"},
+		},
+		{
+			name:   "lint with problems",
+			args:   []string{"lint", "./testdata/api.Conflicts"},
+			code:   1,
+			stdout: "conflict: GET /{y}/b: " + conflict,
+		},
+		{
+			name:   "invalid function",
+			args:   []string{"api"},
+			code:   2,
+			stderr: "invalid function: 'api' (expected: <package>.<function>)",
+		},
+		{
+			name:   "unknown package",
+			args:   []string{"./testdata/missing.Routes"},
+			code:   2,
+			stderr: "package './testdata/missing' not found",
+		},
+		{
+			name:   "invalid signature",
+			args:   []string{"lint", "./testdata/api.Handler"},
+			code:   2,
+			stderr: "cannot load './testdata/api.Handler'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := command(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("exit code expected: %d, got: %d (%s)", tt.code, code, stderr.String())
+			}
+			if !strings.HasPrefix(stdout.String(), tt.stdout) || tt.stdout == "" && stdout.Len() > 0 {
+				t.Errorf("output expected: %q, got: %q", tt.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("error expected: %q, got: %q", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestCommandJSON(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := command([]string{"--json", "./testdata/api.Routes"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code expected: 0, got: %d (%s)", code, stderr.String())
+	}
+	var routes []map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"method": "GET", "pattern": "/users"},
+		{"method": "GET", "pattern": "/users/{id}", "name": "user"},
+		{"method": "POST", "pattern": "/users", "host": "api.example.com"},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("routes expected: %v, got: %v", want, routes)
+	}
+
+	stdout.Reset()
+	if code := command([]string{"lint", "--json", "./testdata/api.Conflicts"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code expected: 1, got: %d (%s)", code, stderr.String())
+	}
+	var problems []map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &problems); err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0]["kind"] != "conflict" || problems[0]["route"] != "GET /{y}/b" ||
+		problems[1]["kind"] != "no method" || problems[1]["route"] != "/any" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
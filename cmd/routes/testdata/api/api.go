@@ -0,0 +1,27 @@
+// Package api holds the router-building functions of the tests of the routes command.
+package api
+
+import (
+	"net/http"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+var h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+// Routes builds a valid route table.
+func Routes(router *mux.Mux) {
+	router.GET("/users").Then(h)
+	router.GET("/users/{id:int}").Name("user").Then(h)
+	router.Host("api.example.com").POST("/users").Then(h)
+}
+
+// Conflicts builds a route table with problems.
+func Conflicts(router *mux.Mux) {
+	router.GET("/a/{x}").Then(h)
+	router.GET("/{y}/b").Then(h)
+	router.Handle("", "/any").Then(h)
+}
+
+// Handler doesn't build a route table.
+func Handler(w http.ResponseWriter, r *http.Request) {}
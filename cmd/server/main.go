@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/carlito767/go-stack/clp"
 	"github.com/carlito767/go-stack/middleware"
@@ -32,10 +31,12 @@ func main() {
 
 	// set routes
 	router.GET("/panic").ThenFunc(panicHandler)
-	router.GET("/status/{code}").ThenFunc(statusHandler)
+	router.GET("/status/{code:int}").ThenFunc(statusHandler)
 
-	// handle all other routes
-	router.Handle("", "/").ThenFunc(http.NotFound)
+	// print routes
+	for _, route := range router.Routes() {
+		fmt.Printf("%-7s %s\n", route.Method, route.Pattern)
+	}
 
 	// start server
 	addr := fmt.Sprintf("%v:%v", config.Host, config.Port)
@@ -48,7 +49,7 @@ func panicHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func statusHandler(w http.ResponseWriter, r *http.Request) {
-	code, _ := strconv.Atoi(r.PathValue("code"))
+	code, _ := mux.ParamInt(r, "code") // validated by the route constraint
 	status := http.StatusText(code)
 	if status == "" {
 		w.WriteHeader(http.StatusBadRequest)
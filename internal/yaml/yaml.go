@@ -0,0 +1,572 @@
+// Package yaml implements the parsing of the subset of YAML used by the API
+// specifications (see mux.LoadSpec).
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse parses a YAML document into the values of encoding/json
+// (map[string]any, []any, string, float64, bool and nil).
+//
+// Only the subset of YAML used by the API specifications is supported:
+// block mappings and sequences, flow collections, plain and quoted scalars
+// (on a single line), literal and folded block scalars, and comments. Anchors,
+// aliases, tags and multiple documents are not supported.
+func Parse(data []byte) (any, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+	if !p.next() {
+		return nil, nil
+	}
+	v, err := p.node(p.indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.next() && p.text != "..." {
+		return nil, p.errorf("unexpected content")
+	}
+	return v, nil
+}
+
+type yamlParser struct {
+	lines  []string
+	i      int    // index of the current line
+	indent int    // indentation of the current line
+	text   string // current line, without indentation and comment
+}
+
+// next moves to the next significant line (the current line if significant),
+// and reports whether there is one.
+func (p *yamlParser) next() bool {
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		text := strings.TrimLeft(line, " ")
+		text = stripComment(text)
+		if text == "" || text == "---" && p.i == 0 {
+			continue
+		}
+		p.indent = len(line) - len(strings.TrimLeft(line, " "))
+		p.text = text
+		return true
+	}
+	return false
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("yaml: line %d: %s", p.i+1, fmt.Sprintf(format, args...))
+}
+
+// node parses the node of the current line, with the given indentation.
+func (p *yamlParser) node(indent int) (any, error) {
+	if isSequenceItem(p.text) {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitKey(p.text); ok {
+		return p.mapping(indent)
+	}
+	v, err := p.value(indent)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// sequence parses a block sequence.
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.next() && p.indent == indent && isSequenceItem(p.text) {
+		rest := strings.TrimLeft(p.text[1:], " ")
+		if rest == "" {
+			p.i++
+			v, err := p.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		// The item is parsed as a node at the column of its content (e.g. "- key: value").
+		n := p.indent + len(p.text) - len(rest)
+		p.lines[p.i] = strings.Repeat(" ", n) + rest
+		p.next()
+		v, err := p.node(n)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	if p.i < len(p.lines) && p.indent > indent {
+		return nil, p.errorf("bad indentation")
+	}
+	return items, nil
+}
+
+// mapping parses a block mapping.
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.next() && p.indent == indent && !isSequenceItem(p.text) {
+		key, rest, ok := splitKey(p.text)
+		if !ok {
+			return nil, p.errorf("expected a mapping key")
+		}
+		k := key
+		if key[0] == '"' || key[0] == '\'' {
+			v, err := p.scalar(key)
+			if err != nil {
+				return nil, err
+			}
+			k = v.(string)
+		}
+		if _, found := m[k]; found {
+			return nil, p.errorf("duplicate key: '%s'", k)
+		}
+		var v any
+		var err error
+		if rest == "" {
+			p.i++
+			v, err = p.child(indent)
+		} else {
+			p.text = rest
+			v, err = p.value(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	if p.i < len(p.lines) && p.indent > indent {
+		return nil, p.errorf("bad indentation")
+	}
+	return m, nil
+}
+
+// child parses the node after a key or a sequence indicator without value:
+// a more indented node, a sequence at the same indentation (for a key), or null.
+func (p *yamlParser) child(indent int) (any, error) {
+	if !p.next() {
+		return nil, nil
+	}
+	if p.indent > indent || p.indent == indent && isSequenceItem(p.text) && p.isMappingValue(indent) {
+		return p.node(p.indent)
+	}
+	return nil, nil
+}
+
+// isMappingValue reports whether the previous significant line is a mapping key
+// at the given indentation (a sequence can be at the same indentation as its key).
+func (p *yamlParser) isMappingValue(indent int) bool {
+	for i := p.i - 1; i >= 0; i-- {
+		line := p.lines[i]
+		text := stripComment(strings.TrimLeft(line, " "))
+		if text == "" {
+			continue
+		}
+		return len(line)-len(strings.TrimLeft(line, " ")) == indent && !isSequenceItem(text)
+	}
+	return false
+}
+
+// value parses the value of the current line: a block scalar,
+// a flow collection, or a scalar.
+func (p *yamlParser) value(indent int) (any, error) {
+	text := p.text
+	switch {
+	case text[0] == '|' || text[0] == '>':
+		return p.blockScalar(indent)
+	case text[0] == '[' || text[0] == '{':
+		// A flow collection can span several lines.
+		for !balanced(text) {
+			p.i++
+			if !p.next() {
+				return nil, p.errorf("unterminated flow collection")
+			}
+			text += " " + p.text
+		}
+		f := &flowParser{s: text}
+		v, err := f.value()
+		if err == nil {
+			f.skipSpaces()
+			if f.i < len(f.s) {
+				err = fmt.Errorf("unexpected '%s'", f.s[f.i:])
+			}
+		}
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.i++
+		return v, nil
+	}
+	v, err := p.scalar(text)
+	if err != nil {
+		return nil, err
+	}
+	p.i++
+	return v, nil
+}
+
+// blockScalar parses a literal (|) or folded (>) block scalar.
+func (p *yamlParser) blockScalar(indent int) (any, error) {
+	header := p.text
+	folded := header[0] == '>'
+	chomping := byte(0)
+	for _, c := range []byte(header[1:]) {
+		switch {
+		case c == '-' || c == '+':
+			chomping = c
+		case c >= '1' && c <= '9':
+		default:
+			return nil, p.errorf("bad block scalar header: '%s'", header)
+		}
+	}
+
+	var lines []string
+	blockIndent := -1
+	p.i++
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := len(line) - len(trimmed)
+		if n <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		if n < blockIndent {
+			break
+		}
+		lines = append(lines, line[blockIndent:])
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			switch {
+			case !folded || line == "" || line[0] == ' ' || lines[i-1] != "" && lines[i-1][0] == ' ':
+				b.WriteByte('\n')
+			case lines[i-1] != "":
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	s := b.String()
+	switch {
+	case len(lines) == 0:
+	case chomping == '+':
+		s += strings.Repeat("\n", trailing+1)
+	case chomping == 0:
+		s += "\n"
+	}
+	return s, nil
+}
+
+// scalar parses a plain or quoted scalar.
+func (p *yamlParser) scalar(text string) (any, error) {
+	v, err := parseScalar(text)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return v, nil
+}
+
+// parseScalar parses a plain or quoted scalar.
+func parseScalar(text string) (any, error) {
+	switch text[0] {
+	case '"':
+		if len(text) < 2 || text[len(text)-1] != '"' {
+			return nil, fmt.Errorf("unterminated string: %s", text)
+		}
+		s, ok := unquote(text[1 : len(text)-1])
+		if !ok {
+			return nil, fmt.Errorf("bad string: %s", text)
+		}
+		return s, nil
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string: %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '&', '*', '!':
+		return nil, fmt.Errorf("unsupported feature: %s", text)
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if isNumber(text) {
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v, nil
+		}
+	}
+	return text, nil
+}
+
+// escapes are the escape sequences of the double-quoted scalars, except the
+// Unicode escapes (\x, \u and \U).
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v",
+	'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// unquote returns the content of a double-quoted scalar, without its quotes,
+// with its escape sequences decoded.
+func unquote(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			return "", false
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i++; i == len(s) {
+			return "", false
+		}
+		if escape, found := escapes[s[i]]; found {
+			b.WriteString(escape)
+			continue
+		}
+		var size int
+		switch s[i] {
+		case 'x':
+			size = 2
+		case 'u':
+			size = 4
+		case 'U':
+			size = 8
+		default:
+			return "", false
+		}
+		if i+size >= len(s) {
+			return "", false
+		}
+		r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return "", false
+		}
+		b.WriteRune(rune(r))
+		i += size
+	}
+	return b.String(), true
+}
+
+// isNumber reports whether the plain scalar is a decimal number.
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if s == "" || s[0] == '.' && len(s) == 1 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && !strings.ContainsRune(".eE+-", c) {
+			return false
+		}
+	}
+	return s[0] >= '0' && s[0] <= '9' || s[0] == '.'
+}
+
+// isSequenceItem reports whether the line is a block sequence item.
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits a mapping line into its key and its value.
+func splitKey(text string) (string, string, bool) {
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	quote := byte(0)
+	if text[0] == '"' || text[0] == '\'' {
+		quote = text[0]
+	}
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == ':' && (i == len(text)-1 || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes the comment of the line, outside of the quoted strings.
+func stripComment(text string) string {
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" [{,:", rune(text[i-1]))):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// balanced reports whether the brackets of the flow collection are balanced.
+func balanced(text string) bool {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// flowParser parses a flow collection.
+type flowParser struct {
+	s string
+	i int
+}
+
+func (f *flowParser) skipSpaces() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *flowParser) value() (any, error) {
+	f.skipSpaces()
+	if f.i >= len(f.s) {
+		return nil, fmt.Errorf("unexpected end of flow collection")
+	}
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		items := []any{}
+		for {
+			f.skipSpaces()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				return items, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.i++
+		m := map[string]any{}
+		for {
+			f.skipSpaces()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				return m, nil
+			}
+			key, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpaces()
+			if f.i >= len(f.s) || f.s[f.i] != ':' {
+				return nil, fmt.Errorf("expected ':' in flow mapping")
+			}
+			f.i++
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = v
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f.scalar()
+}
+
+// separator consumes the separator after a flow item (the closing bracket is kept).
+func (f *flowParser) separator(end byte) error {
+	f.skipSpaces()
+	switch {
+	case f.i < len(f.s) && f.s[f.i] == ',':
+		f.i++
+		return nil
+	case f.i < len(f.s) && f.s[f.i] == end:
+		return nil
+	}
+	return fmt.Errorf("expected ',' or '%c' in flow collection", end)
+}
+
+func (f *flowParser) scalar() (any, error) {
+	start := f.i
+	if c := f.s[f.i]; c == '"' || c == '\'' {
+		for f.i++; f.i < len(f.s); f.i++ {
+			if f.s[f.i] == '\\' && c == '"' {
+				f.i++
+			} else if f.s[f.i] == c {
+				if c == '\'' && f.i+1 < len(f.s) && f.s[f.i+1] == '\'' {
+					f.i++
+					continue
+				}
+				f.i++
+				break
+			}
+		}
+	} else {
+		for ; f.i < len(f.s); f.i++ {
+			c := f.s[f.i]
+			if c == ',' || c == ']' || c == '}' || c == ':' && (f.i+1 == len(f.s) || strings.ContainsRune(" ,]}", rune(f.s[f.i+1]))) {
+				break
+			}
+		}
+	}
+	text := strings.TrimSpace(f.s[start:f.i])
+	if text == "" {
+		return nil, fmt.Errorf("empty value in flow collection")
+	}
+	return parseScalar(text)
+}
@@ -0,0 +1,66 @@
+package yaml_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/carlito767/go-stack/internal/yaml"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want any
+	}{
+		{"empty", "", nil},
+		{"mapping", "a: 1\nb:\n  c: true\n  d: ~\n", map[string]any{"a": 1.0, "b": map[string]any{"c": true, "d": nil}}},
+		{"sequence", "- a\n- 'b'\n- [c, 2]\n", []any{"a", "b", []any{"c", 2.0}}},
+		{"flow mapping", "a: {b: \"c\", d: [1]}\n", map[string]any{"a": map[string]any{"b": "c", "d": []any{1.0}}}},
+		{"comment", "# comment\na: b # comment\n", map[string]any{"a": "b"}},
+		{"literal", "a: |\n  x\n  y\n", map[string]any{"a": "x\ny\n"}},
+		{"folded", "a: >\n  x\n  y\n", map[string]any{"a": "x y\n"}},
+		{"single quotes", `a: 'it''s \n'`, map[string]any{"a": `it's \n`}},
+		{"escapes", `a: "\0\a\b\t\	\n\v\f\r\e\ \"\/\\"`, map[string]any{"a": "\x00\a\b\t\t\n\v\f\r\x1b \"/\\"}},
+		{"unicode escapes", `a: "\N\_\L\P \x41é\U0001F600"`, map[string]any{"a": "\u0085\u00a0\u2028\u2029 Aé\U0001F600"}},
+		{"escapes in flow", `a: ["x\/y", "A"]`, map[string]any{"a": []any{"x/y", "A"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yaml.Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"bad indentation", "a: 1\n  b: 2\n", "yaml: line 2: bad indentation"},
+		{"unterminated string", `a: "x`, `yaml: line 1: unterminated string: "x`},
+		{"unknown escape", `a: "\q"`, `yaml: line 1: bad string: "\q"`},
+		{"go escape", `a: "\'"`, `yaml: line 1: bad string: "\'"`},
+		{"short unicode escape", `a: "\u00"`, `yaml: line 1: bad string: "\u00"`},
+		{"invalid unicode escape", `a: "\UFFFFFFFF"`, `yaml: line 1: bad string: "\UFFFFFFFF"`},
+		{"unescaped quote", `a: "x"y"`, `yaml: line 1: bad string: "x"y"`},
+		{"anchor", "a: &x 1\n", "yaml: line 1: unsupported feature: &x 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := yaml.Parse([]byte(tt.data))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error expected: %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
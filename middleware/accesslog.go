@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access log formats (see NewAccessLogger).
+const (
+	// CommonLogFormat is the NCSA Common Log Format.
+	CommonLogFormat = `%h %l %u %t "%r" %>s %b`
+	// CombinedLogFormat is the NCSA Combined Log Format (Apache/NGINX default).
+	CombinedLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// NewAccessLogger creates a middleware that writes one line per request to out,
+// with the given format (e.g. CommonLogFormat, CombinedLogFormat).
+//
+// The format follows the Apache mod_log_config syntax, with these directives:
+//
+//	%%          a percent sign
+//	%a, %h      the IP address of the client
+//	%l          the remote logname (always "-")
+//	%u          the user of the basic authentication, or "-"
+//	%t          the time the request was received, e.g. [10/Oct/2000:13:55:36 -0700]
+//	%r          the request line, e.g. GET /index.html HTTP/1.1
+//	%m          the request method
+//	%U          the request path
+//	%q          the query string, with a leading "?" (empty if none)
+//	%H          the request protocol
+//	%v          the host of the request
+//	%s, %>s     the status code
+//	%b          the number of bytes of the response body, or "-" if none
+//	%B          the number of bytes of the response body
+//	%D          the time taken to serve the request, in microseconds
+//	%T          the time taken to serve the request, in seconds
+//	%L          the request ID, or "-" (see RequestID)
+//	%{Name}i    the Name header of the request, or "-"
+//	%{Name}o    the Name header of the response, or "-"
+//
+// The quotes, backslashes and control characters of the request line and of the
+// headers are escaped. NewAccessLogger panics if the format is invalid.
+//
+// Example:
+//
+//	file, err := middleware.NewRotatingFile("access.log", middleware.RotateOptions{MaxSize: 100 << 20})
+//	...
+//	router.Use(middleware.NewAccessLogger(file, middleware.CombinedLogFormat, middleware.MockTimeProvider{}))
+func NewAccessLogger(out io.Writer, format string, tp TimeProvider) func(next http.Handler) http.Handler {
+	fields, err := parseLogFormat(format)
+	if err != nil {
+		panic(err)
+	}
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w, tp)
+
+			e := &accessEntry{r: r, ww: ww, start: tp.Now()}
+			defer func() {
+				e.duration = tp.Since(e.start)
+				var b bytes.Buffer
+				for _, field := range fields {
+					field(&b, e)
+				}
+				b.WriteByte('\n')
+				mu.Lock()
+				defer mu.Unlock()
+				out.Write(b.Bytes())
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// accessEntry holds the data of a request written to the access log.
+type accessEntry struct {
+	r        *http.Request
+	ww       WrapResponseWriter
+	start    time.Time
+	duration time.Duration
+}
+
+// logField writes a field of an access log line.
+type logField func(b *bytes.Buffer, e *accessEntry)
+
+// parseLogFormat returns the fields of the access log format.
+func parseLogFormat(format string) ([]logField, error) {
+	var fields []logField
+	literal := func(s string) {
+		if s != "" {
+			fields = append(fields, func(b *bytes.Buffer, _ *accessEntry) { b.WriteString(s) })
+		}
+	}
+	for {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			literal(format)
+			return fields, nil
+		}
+		literal(format[:i])
+		directive := format[i:]
+		format = format[i+1:]
+
+		arg := ""
+		if strings.HasPrefix(format, "{") {
+			end := strings.IndexByte(format, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated directive in log format: '%s'", directive)
+			}
+			arg, format = format[1:end], format[end+1:]
+		}
+		format = strings.TrimPrefix(format, ">") // final status
+		if format == "" {
+			return nil, fmt.Errorf("incomplete directive in log format: '%s'", directive)
+		}
+		field, err := logDirective(format[0], arg)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		format = format[1:]
+	}
+}
+
+// logDirective returns the field of the directive (see NewAccessLogger).
+func logDirective(c byte, arg string) (logField, error) {
+	if arg != "" && c != 'i' && c != 'o' || arg == "" && (c == 'i' || c == 'o') {
+		return nil, fmt.Errorf("invalid directive in log format: '%%{%s}%c'", arg, c)
+	}
+	switch c {
+	case '%':
+		return func(b *bytes.Buffer, _ *accessEntry) { b.WriteByte('%') }, nil
+	case 'a', 'h':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			host, _, err := net.SplitHostPort(e.r.RemoteAddr)
+			if err != nil {
+				host = e.r.RemoteAddr
+			}
+			writeLogValue(b, host)
+		}, nil
+	case 'l':
+		return func(b *bytes.Buffer, _ *accessEntry) { b.WriteByte('-') }, nil
+	case 'u':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			user, _, _ := e.r.BasicAuth()
+			writeLogValue(b, user)
+		}, nil
+	case 't':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			b.WriteString(e.start.Format("[02/Jan/2006:15:04:05 -0700]"))
+		}, nil
+	case 'r':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			uri := e.r.RequestURI
+			if uri == "" {
+				uri = e.r.URL.RequestURI()
+			}
+			writeLogValue(b, e.r.Method+" "+uri+" "+e.r.Proto)
+		}, nil
+	case 'm':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.r.Method) }, nil
+	case 'U':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.r.URL.EscapedPath()) }, nil
+	case 'q':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			if e.r.URL.RawQuery != "" {
+				b.WriteByte('?')
+				b.WriteString(escapeLogValue(e.r.URL.RawQuery))
+			}
+		}, nil
+	case 'H':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.r.Proto) }, nil
+	case 'v':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.r.Host) }, nil
+	case 's':
+		return func(b *bytes.Buffer, e *accessEntry) { b.WriteString(strconv.Itoa(status(e.ww))) }, nil
+	case 'b':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			if e.ww.BytesWritten() == 0 {
+				b.WriteByte('-')
+				return
+			}
+			b.WriteString(strconv.FormatInt(e.ww.BytesWritten(), 10))
+		}, nil
+	case 'B':
+		return func(b *bytes.Buffer, e *accessEntry) { b.WriteString(strconv.FormatInt(e.ww.BytesWritten(), 10)) }, nil
+	case 'D':
+		return func(b *bytes.Buffer, e *accessEntry) { b.WriteString(strconv.FormatInt(e.duration.Microseconds(), 10)) }, nil
+	case 'T':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			b.WriteString(strconv.FormatInt(int64(e.duration.Seconds()), 10))
+		}, nil
+	case 'L':
+		return func(b *bytes.Buffer, e *accessEntry) {
+			id, _ := RequestIDFromContext(e.r.Context())
+			writeLogValue(b, id)
+		}, nil
+	case 'i':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.r.Header.Get(arg)) }, nil
+	case 'o':
+		return func(b *bytes.Buffer, e *accessEntry) { writeLogValue(b, e.ww.Header().Get(arg)) }, nil
+	}
+	return nil, fmt.Errorf("unknown directive in log format: '%%%c'", c)
+}
+
+// writeLogValue writes the escaped value, or "-" if the value is empty.
+func writeLogValue(b *bytes.Buffer, s string) {
+	if s == "" {
+		b.WriteByte('-')
+		return
+	}
+	b.WriteString(escapeLogValue(s))
+}
+
+// escapeLogValue escapes the quotes, the backslashes and the control characters,
+// like Apache.
+func escapeLogValue(s string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { return r == '"' || r == '\\' || r < 0x20 || r == 0x7f }) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/carlito767/go-stack/middleware"
+)
+
+func TestAccessLogger(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		request     func() *http.Request
+		expectedLog string
+	}{
+		{
+			name:   "common",
+			format: middleware.CommonLogFormat,
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/items?page=2", nil)
+				req.SetBasicAuth("frank", "secret")
+				return req
+			},
+			expectedLog: `192.0.2.1 - frank [01/Jan/2023:12:00:00 +0000] "GET /items?page=2 HTTP/1.1" 200 5` + "\n",
+		},
+		{
+			name:   "combined",
+			format: middleware.CombinedLogFormat,
+			request: func() *http.Request {
+				req := httptest.NewRequest("POST", "/items", nil)
+				req.Header.Set("Referer", "http://example.com/")
+				req.Header.Set("User-Agent", `Mozilla/5.0 "quoted"`)
+				return req
+			},
+			expectedLog: `192.0.2.1 - - [01/Jan/2023:12:00:00 +0000] "POST /items HTTP/1.1" 200 5 "http://example.com/" "Mozilla/5.0 \"quoted\""` + "\n",
+		},
+		{
+			name:   "custom",
+			format: `%m %U%q %H %v %>s %B %D %T %L %{X-Trace}o %{X-Missing}i 100%%`,
+			request: func() *http.Request {
+				return httptest.NewRequest("GET", "/items?page=2", nil)
+			},
+			expectedLog: "GET /items?page=2 HTTP/1.1 example.com 200 5 42000000 42 - handler - 100%\n",
+		},
+		{
+			name:   "empty response",
+			format: `"%r" %s %b %B`,
+			request: func() *http.Request {
+				return httptest.NewRequest("HEAD", "/items", nil)
+			},
+			expectedLog: "\"HEAD /items HTTP/1.1\" 204 - 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Trace", "handler")
+				if r.Method == "HEAD" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Write([]byte("hello"))
+			})
+
+			var buf bytes.Buffer
+			logger := middleware.NewAccessLogger(&buf, tt.format, middleware.FakeTimeProvider{})(handler)
+			logger.ServeHTTP(httptest.NewRecorder(), tt.request())
+
+			if log := buf.String(); log != tt.expectedLog {
+				t.Errorf("log expected:%q, got:%q", tt.expectedLog, log)
+			}
+		})
+	}
+}
+
+func TestAccessLoggerInvalidFormat(t *testing.T) {
+	for _, format := range []string{`%h %`, `%{Referer`, `%{Referer}t`, `%i`, `%Z`} {
+		t.Run(format, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("the code did not panic")
+				}
+			}()
+			middleware.NewAccessLogger(&bytes.Buffer{}, format, middleware.FakeTimeProvider{})
+		})
+	}
+}
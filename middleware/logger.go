@@ -1,73 +1,96 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+
+	"github.com/carlito767/go-stack/mux"
 )
 
 // Logger is a middleware that logs every request with some useful data.
 var Logger = NewLogger(MockTimeProvider{}, true)
 
-// NewLogger creates a middleware that logs every request with some useful data.
+// NewLogger creates a middleware that logs every request with some useful data,
+// in a human-readable format on the standard output (see TerminalHandler).
 func NewLogger(tp TimeProvider, color bool) func(next http.Handler) http.Handler {
+	return NewStructuredLogger(slog.New(NewTerminalHandler(os.Stdout, color)), tp)
+}
+
+// NewStructuredLogger creates a middleware that logs one record per request
+// with the logger, with these attributes:
+//
+//	method       the request method
+//	route        the pattern of the matched route, if any (see mux.RouteFromContext)
+//	url          the request URL
+//	status       the response status code
+//	bytes        the number of bytes of the response body
+//	duration     the time taken to serve the request
+//	remote_addr  the network address of the client
+//	user_agent   the User-Agent header of the request, if any
+//	request_id   the request ID, if any (see RequestID)
+//
+// The level of the record is Error for the 5xx status codes, Warn for the 4xx
+// status codes, and Info otherwise.
+//
+// Example:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+//	router.Use(middleware.NewStructuredLogger(logger, middleware.MockTimeProvider{}))
+func NewStructuredLogger(logger *slog.Logger, tp TimeProvider) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			lrw := newLoggingResponseWriter(w)
+			ww := NewWrapResponseWriter(w, tp)
 
 			t := tp.Now()
 			defer func() {
-				statusCode := lrw.statusCode
-				var colorSeq, resetSeq string
-				if color {
-					// https://zetcode.com/golang/terminal-colour/
-					switch {
-					case statusCode >= 100 && statusCode <= 199:
-						// 1xx informational response
-						colorSeq = "\033[34m" // blue
-					case statusCode >= 200 && statusCode <= 299:
-						// 2xx success
-						colorSeq = "\033[32m" // green
-					case statusCode >= 300 && statusCode <= 399:
-						// 3xx redirection
-						colorSeq = "\033[33m" // yellow
-					case statusCode >= 400 && statusCode <= 499:
-						// 4xx client errors
-						colorSeq = "\033[31m" // red
-					case statusCode >= 500 && statusCode <= 599:
-						// 5xx server errors
-						colorSeq = "\033[35m" // purple
-					}
-					resetSeq = "\033[0m" // reset color
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+				}
+				if route, ok := mux.RouteFromContext(r.Context()); ok {
+					attrs = append(attrs, slog.String("route", route.Pattern))
+				} else if r.Pattern != "" {
+					attrs = append(attrs, slog.String("route", r.Pattern))
+				}
+				attrs = append(attrs,
+					slog.String("url", r.URL.String()),
+					slog.Int("status", status(ww)),
+					slog.Int64("bytes", ww.BytesWritten()),
+					slog.Duration("duration", tp.Since(t)),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+				if userAgent := r.UserAgent(); userAgent != "" {
+					attrs = append(attrs, slog.String("user_agent", userAgent))
 				}
-				fmt.Printf("[%s] %q (%v)\n", r.Method, r.URL.String(), tp.Since(t))
-				msg := fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode))
-				fmt.Printf("%s%s%s\n", colorSeq, msg, resetSeq)
+				if requestID, ok := RequestIDFromContext(r.Context()); ok {
+					attrs = append(attrs, slog.String("request_id", requestID))
+				}
+				logger.LogAttrs(r.Context(), statusLevel(status(ww)), "request", attrs...)
 			}()
 
-			next.ServeHTTP(lrw, r)
+			next.ServeHTTP(ww, r)
 		}
 
 		return http.HandlerFunc(fn)
 	}
 }
 
-//
-// Logging Response Writer
-// https://ndersson.me/post/capturing_status_code_in_net_http/
-//
-
-type loggingResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func newLoggingResponseWriter(w http.ResponseWriter) *loggingResponseWriter {
-	// WriteHeader(int) is not called if our response implicitly returns 200 OK, so
-	// we default to that status code.
-	return &loggingResponseWriter{w, http.StatusOK}
+// status returns the status code of the response, or 200 OK if the handler
+// didn't write any header (the status code sent by the server).
+func status(ww WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK
+	}
+	return ww.Status()
 }
 
-func (lrw *loggingResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
-	lrw.ResponseWriter.WriteHeader(code)
+// statusLevel returns the log level of a request with the status code.
+func statusLevel(statusCode int) slog.Level {
+	switch {
+	case statusCode >= 500:
+		return slog.LevelError
+	case statusCode >= 400:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
 }
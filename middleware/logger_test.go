@@ -1,13 +1,18 @@
 package middleware_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/carlito767/go-stack/middleware"
+	"github.com/carlito767/go-stack/mux"
 )
 
 func TestLogger(t *testing.T) {
@@ -95,3 +100,177 @@ func TestLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggerHijack(t *testing.T) {
+	osStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() {
+		os.Stdout = osStdout
+	}()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		brw.Flush()
+	})
+	logger := middleware.NewLogger(middleware.FakeTimeProvider{}, false)(handler)
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		logger.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	<-done
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status code expected:%d, got:%d", http.StatusSwitchingProtocols, res.StatusCode)
+	}
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if log, expectedLog := string(out), "[GET] \"/ws\" (42s)\n101 Switching Protocols\n"; log != expectedLog {
+		t.Errorf("log expected:%q, got:%q", expectedLog, log)
+	}
+}
+
+func TestLoggerFlush(t *testing.T) {
+	osStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() {
+		w.Close()
+		os.Stdout = osStdout
+	}()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("response writer is not a flusher")
+		}
+		w.Write([]byte("data: 1\n\n"))
+		f.Flush()
+	})
+	logger := middleware.NewLogger(middleware.FakeTimeProvider{}, false)(handler)
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	res := httptest.NewRecorder()
+	logger.ServeHTTP(res, req)
+
+	if !res.Flushed {
+		t.Error("response expected to be flushed")
+	}
+}
+
+func TestStructuredLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		header   http.Header
+		expected map[string]any
+	}{
+		{
+			name:   "matched route",
+			path:   "/status/201?verbose=1",
+			header: http.Header{"User-Agent": {"test/1.0"}, "X-Request-Id": {"abc123"}},
+			expected: map[string]any{
+				"level":       "INFO",
+				"msg":         "request",
+				"method":      "GET",
+				"route":       "/status/{code}",
+				"url":         "/status/201?verbose=1",
+				"status":      201.0,
+				"bytes":       6.0,
+				"duration":    42e9,
+				"remote_addr": "192.0.2.1:1234",
+				"user_agent":  "test/1.0",
+				"request_id":  "abc123",
+			},
+		},
+		{
+			name: "client error",
+			path: "/status/404",
+			expected: map[string]any{
+				"level":       "WARN",
+				"msg":         "request",
+				"method":      "GET",
+				"route":       "/status/{code}",
+				"url":         "/status/404",
+				"status":      404.0,
+				"bytes":       6.0,
+				"duration":    42e9,
+				"remote_addr": "192.0.2.1:1234",
+				"request_id":  "generated",
+			},
+		},
+		{
+			name: "server error",
+			path: "/status/503",
+			expected: map[string]any{
+				"level":       "ERROR",
+				"msg":         "request",
+				"method":      "GET",
+				"route":       "/status/{code}",
+				"url":         "/status/503",
+				"status":      503.0,
+				"bytes":       6.0,
+				"duration":    42e9,
+				"remote_addr": "192.0.2.1:1234",
+				"request_id":  "generated",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+
+			router := mux.NewRouter()
+			requestID := middleware.NewRequestID(middleware.RequestIDOptions{
+				Header:    "X-Request-ID",
+				MaxLength: 64,
+				Generator: func() string { return "generated" },
+			})
+			router.Use(requestID, middleware.NewStructuredLogger(logger, middleware.FakeTimeProvider{}))
+			router.GET("/status/{code:int}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+				code, _ := mux.ParamInt(r, "code")
+				w.WriteHeader(code)
+				w.Write([]byte("status"))
+			})
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("invalid record: %v (%q)", err, buf.String())
+			}
+			if !reflect.DeepEqual(record, tt.expected) {
+				t.Errorf("record expected:%v, got:%v", tt.expected, record)
+			}
+		})
+	}
+}
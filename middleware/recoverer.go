@@ -1,20 +1,185 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"mime"
 	"net/http"
+	"runtime/debug"
+	"strings"
 )
 
-// Recoverer is a middleware that recovers from panics that occur during the execution of a request.
-func Recoverer(next http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				fmt.Fprintln(w, err)
+// Recoverer is a middleware that recovers from panics that occur during the execution of a request,
+// with DefaultRecovererOptions.
+var Recoverer = NewRecoverer(DefaultRecovererOptions)
+
+// ErrorFormat is the format of the response rendered by the Recoverer.
+type ErrorFormat int
+
+const (
+	// ErrorFormatAuto renders the response as JSON or HTML, whichever comes first
+	// in the Accept header of the request, and as plain text otherwise.
+	ErrorFormatAuto ErrorFormat = iota
+	// ErrorFormatText renders the response as plain text.
+	ErrorFormatText
+	// ErrorFormatJSON renders the response as JSON ({"error": "Internal Server Error"}).
+	ErrorFormatJSON
+	// ErrorFormatHTML renders the response as an HTML page.
+	ErrorFormatHTML
+)
+
+// RecovererOptions defines how the panics are recovered.
+type RecovererOptions struct {
+	// Stack captures the stack trace of the panics.
+	Stack bool
+	// Logger reports the panics at the Error level (slog.Default() if nil).
+	// The panics are not logged with a logger discarding the records (slog.DiscardHandler).
+	Logger *slog.Logger
+	// Report is called with the panics, after the Logger (e.g. to send them to an error tracker).
+	Report func(r *http.Request, p *Panic)
+	// Format is the format of the 500 Internal Server Error response.
+	Format ErrorFormat
+}
+
+// DefaultRecovererOptions are the options of Recoverer.
+var DefaultRecovererOptions = RecovererOptions{
+	Stack: true,
+}
+
+// Panic is a panic recovered by a Recoverer.
+type Panic struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panic, if captured.
+	Stack []byte
+	// RequestID is the ID of the request, if any (see RequestID).
+	RequestID string
+}
+
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (p *Panic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// NewRecoverer creates a middleware that recovers from panics that occur during
+// the execution of a request, reports them, and renders a generic 500 Internal
+// Server Error response: the panic value is never sent to the client.
+//
+// The response is only rendered if the handler didn't write its header. Otherwise,
+// the handler is aborted with http.ErrAbortHandler, so that the client doesn't
+// take a truncated response for a complete one. The http.ErrAbortHandler panics
+// are not recovered.
+//
+// Example:
+//
+//	router.Use(middleware.NewRecoverer(middleware.RecovererOptions{
+//		Stack:  true,
+//		Logger: logger,
+//		Report: func(r *http.Request, p *middleware.Panic) { tracker.Capture(p) },
+//		Format: middleware.ErrorFormatJSON,
+//	}))
+func NewRecoverer(options RecovererOptions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w, MockTimeProvider{})
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				p := &Panic{Value: v}
+				p.RequestID, _ = RequestIDFromContext(r.Context())
+				if options.Stack {
+					p.Stack = debug.Stack()
+				}
+				logger := options.Logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+				attrs := []slog.Attr{
+					slog.Any("panic", v),
+					slog.String("method", r.Method),
+					slog.String("url", r.URL.String()),
+				}
+				if p.RequestID != "" {
+					attrs = append(attrs, slog.String("request_id", p.RequestID))
+				}
+				if p.Stack != nil {
+					attrs = append(attrs, slog.String("stack", string(p.Stack)))
+				}
+				logger.LogAttrs(r.Context(), slog.LevelError, "panic recovered", attrs...)
+				if options.Report != nil {
+					options.Report(r, p)
+				}
+
+				if ww.WroteHeader() {
+					panic(http.ErrAbortHandler)
+				}
+				renderPanic(ww, r, options.Format)
+			}()
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// renderPanic renders a 500 Internal Server Error response, in the given format.
+func renderPanic(w http.ResponseWriter, r *http.Request, format ErrorFormat) {
+	if format == ErrorFormatAuto {
+		format = acceptedFormat(r)
+	}
+
+	// The headers of the handler describing its response are discarded.
+	for _, key := range []string{"Content-Length", "Content-Encoding", "Content-Disposition", "ETag", "Last-Modified"} {
+		w.Header().Del(key)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusInternalServerError
+	text := http.StatusText(status)
+	switch format {
+	case ErrorFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": text})
+	case ErrorFormatHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head><title>%d %s</title></head>\n<body><h1>%d %s</h1></body>\n</html>\n", status, text, status, text)
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, text)
+	}
+}
+
+// acceptedFormat returns the first format of the Accept header of the request
+// among JSON and HTML, or plain text.
+func acceptedFormat(r *http.Request) ErrorFormat {
+	for _, value := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			switch {
+			case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+				return ErrorFormatJSON
+			case mediaType == "text/html":
+				return ErrorFormatHTML
 			}
-		}()
-		next.ServeHTTP(w, r)
+		}
 	}
-	return http.HandlerFunc(fn)
+	return ErrorFormatText
 }
@@ -1,8 +1,15 @@
 package middleware_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/carlito767/go-stack/middleware"
@@ -24,3 +31,115 @@ func TestRecoverer(t *testing.T) {
 		t.Errorf("status code expected:%d, got:%d", http.StatusInternalServerError, res.Code)
 	}
 }
+
+func TestNewRecoverer(t *testing.T) {
+	tests := []struct {
+		name                string
+		format              middleware.ErrorFormat
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"auto without accept", middleware.ErrorFormatAuto, "", "text/plain; charset=utf-8", "Internal Server Error\n"},
+		{"auto with JSON", middleware.ErrorFormatAuto, "application/problem+json, text/html", "application/json", "{\"error\":\"Internal Server Error\"}\n"},
+		{"auto with HTML", middleware.ErrorFormatAuto, "text/html, application/json;q=0.9", "text/html; charset=utf-8", "<h1>500 Internal Server Error</h1>"},
+		{"text", middleware.ErrorFormatText, "application/json", "text/plain; charset=utf-8", "Internal Server Error\n"},
+		{"JSON", middleware.ErrorFormatJSON, "", "application/json", "{\"error\":\"Internal Server Error\"}\n"},
+		{"HTML", middleware.ErrorFormatHTML, "", "text/html; charset=utf-8", "<h1>500 Internal Server Error</h1>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1000")
+				panic("secret database password")
+			})
+			logger := slog.New(slog.DiscardHandler)
+			recoverer := middleware.NewRecoverer(middleware.RecovererOptions{Logger: logger, Format: tt.format})(handler)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			res := httptest.NewRecorder()
+			recoverer.ServeHTTP(res, req)
+
+			if res.Code != http.StatusInternalServerError {
+				t.Errorf("status code expected:%d, got:%d", http.StatusInternalServerError, res.Code)
+			}
+			if got := res.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("content type expected:%q, got:%q", tt.expectedContentType, got)
+			}
+			if got := res.Header().Get("Content-Length"); got != "" {
+				t.Errorf("content length expected to be removed, got:%q", got)
+			}
+			if body := res.Body.String(); !strings.Contains(body, tt.expectedBody) || strings.Contains(body, "secret") {
+				t.Errorf("body expected:%q, got:%q", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestNewRecovererReport(t *testing.T) {
+	var buf bytes.Buffer
+	var reported *middleware.Panic
+	errSome := errors.New("some error")
+	recoverer := middleware.NewRecoverer(middleware.RecovererOptions{
+		Stack:  true,
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+		Report: func(r *http.Request, p *middleware.Panic) { reported = p },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errSome)
+	}))
+
+	requestID := middleware.NewRequestID(middleware.RequestIDOptions{Header: "X-Request-ID", Generator: func() string { return "req-42" }})
+	requestID(recoverer).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+
+	if reported == nil || !errors.Is(reported, errSome) || !bytes.Contains(reported.Stack, []byte("TestNewRecovererReport")) || reported.RequestID != "req-42" {
+		t.Fatalf("panic expected to be reported with its stack, got:%+v", reported)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid record: %v (%q)", err, buf.String())
+	}
+	if record["level"] != "ERROR" || record["panic"] != "some error" || record["url"] != "/items" || record["request_id"] != "req-42" || record["stack"] != string(reported.Stack) {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
+
+func TestNewRecovererAbort(t *testing.T) {
+	recoverer := middleware.NewRecoverer(middleware.RecovererOptions{Logger: slog.New(slog.DiscardHandler)})
+
+	// the ErrAbortHandler panics are not recovered
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("panic expected:%v, got:%v", http.ErrAbortHandler, r)
+			}
+		}()
+		recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	// the response is aborted if the header has been written
+	server := httptest.NewUnstartedServer(recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		panic("some error")
+	})))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status code expected:%d, got:%d", http.StatusOK, res.StatusCode)
+	}
+	if _, err := io.ReadAll(res.Body); err == nil {
+		t.Errorf("error expected for the aborted response")
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+// RequestID is a middleware that identifies every request, with DefaultRequestIDOptions.
+var RequestID = NewRequestID(DefaultRequestIDOptions)
+
+// RequestIDOptions defines how the requests are identified.
+type RequestIDOptions struct {
+	// Header is the header of the request ID, in the requests and in the responses.
+	Header string
+	// MaxLength is the maximum length of the incoming request IDs
+	// (the incoming request IDs are ignored if 0).
+	MaxLength int
+	// Validate reports whether an incoming request ID is valid. If nil, the request ID
+	// must only contain ASCII letters, digits, and the characters "-_.:".
+	Validate func(id string) bool
+	// Generator generates the request IDs (NewUUIDv7 if nil).
+	Generator func() string
+}
+
+// DefaultRequestIDOptions are the options of RequestID.
+var DefaultRequestIDOptions = RequestIDOptions{
+	Header:    "X-Request-ID",
+	MaxLength: 64,
+	Generator: NewUUIDv7,
+}
+
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+)
+
+// NewRequestID creates a middleware that identifies every request: the incoming
+// request ID, if valid, or a new one is stored in the request context (see
+// RequestIDFromContext) and echoed in the response header.
+//
+// The Logger, the structured and access loggers, and the Recoverer include the
+// request ID: the middleware must be used before them. The request ID is copied
+// onto the outgoing requests by RequestIDTransport.
+//
+// Example:
+//
+//	router.Use(middleware.RequestID, middleware.Logger, middleware.Recoverer)
+func NewRequestID(options RequestIDOptions) func(next http.Handler) http.Handler {
+	if options.Header == "" {
+		panic("request ID header must not be empty")
+	}
+	if options.Validate == nil {
+		options.Validate = validRequestID
+	}
+	if options.Generator == nil {
+		options.Generator = NewUUIDv7
+	}
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(options.Header)
+			if id == "" || len(id) > options.MaxLength || !options.Validate(id) {
+				id = options.Generator()
+			}
+			w.Header().Set(options.Header, id)
+			next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// WithRequestID returns a copy of the context with the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request ID of the context (see RequestID).
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey).(string)
+	return id, ok
+}
+
+// validRequestID reports whether the request ID only contains ASCII letters,
+// digits, and the characters "-_.:".
+func validRequestID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv7 returns a new UUID version 7 (RFC 9562), ordered by creation time
+// (e.g. 01890a5d-ac96-774b-bcce-b302099a8057).
+func NewUUIDv7() string {
+	var uuid [16]byte
+	rand.Read(uuid[6:])
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(uuid[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(uuid[2:], uint32(ms))
+	uuid[6] = uuid[6]&0x0f | 0x70 // version 7
+	uuid[8] = uuid[8]&0x3f | 0x80 // variant 10
+	return mux.UUID(uuid).String()
+}
+
+// crockford is the Crockford's Base32 alphabet of the ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a new ULID, ordered by creation time (e.g. 01ARZ3NDEKTSV4RRFFQ69G5FAV).
+func NewULID() string {
+	var id [16]byte
+	rand.Read(id[6:])
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(id[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:], uint32(ms))
+
+	// 128 bits encoded as 26 characters of 5 bits, the first one with 3 bits
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// RequestIDTransport is an http.RoundTripper copying the request ID of the context
+// of the outgoing requests (see RequestIDFromContext) onto their header.
+//
+// Example:
+//
+//	client := &http.Client{Transport: &middleware.RequestIDTransport{}}
+//	req, err := http.NewRequestWithContext(r.Context(), "GET", url, nil)
+//	...
+//	res, err := client.Do(req)
+type RequestIDTransport struct {
+	// Base is the transport sending the requests (http.DefaultTransport if nil).
+	Base http.RoundTripper
+	// Header is the header of the request ID (the header of DefaultRequestIDOptions if empty).
+	Header string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+// The header of an outgoing request already having a request ID is kept.
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = DefaultRequestIDOptions.Header
+	}
+	if id, ok := RequestIDFromContext(req.Context()); ok && req.Header.Get(header) == "" {
+		// a RoundTripper must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set(header, id)
+	}
+	return base.RoundTrip(req)
+}
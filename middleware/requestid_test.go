@@ -0,0 +1,118 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/middleware"
+)
+
+var (
+	uuidv7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulid   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  middleware.RequestIDOptions
+		header   http.Header
+		expected *regexp.Regexp
+	}{
+		{"generated", middleware.DefaultRequestIDOptions, nil, uuidv7},
+		{"incoming", middleware.DefaultRequestIDOptions, http.Header{"X-Request-Id": {"req-42:a_b.c"}}, regexp.MustCompile(`^req-42:a_b\.c$`)},
+		{"invalid characters", middleware.DefaultRequestIDOptions, http.Header{"X-Request-Id": {"<script>"}}, uuidv7},
+		{"too long", middleware.DefaultRequestIDOptions, http.Header{"X-Request-Id": {strings.Repeat("a", 65)}}, uuidv7},
+		{"incoming ignored", middleware.RequestIDOptions{Header: "X-Request-ID"}, http.Header{"X-Request-Id": {"req-42"}}, uuidv7},
+		{
+			"custom",
+			middleware.RequestIDOptions{
+				Header:    "X-Correlation-ID",
+				MaxLength: 10,
+				Validate:  func(id string) bool { return strings.HasPrefix(id, "corr-") },
+				Generator: middleware.NewULID,
+			},
+			http.Header{"X-Correlation-Id": {"req-42"}},
+			ulid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, _ = middleware.RequestIDFromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			res := httptest.NewRecorder()
+			middleware.NewRequestID(tt.options)(handler).ServeHTTP(res, req)
+
+			if !tt.expected.MatchString(id) {
+				t.Errorf("request ID expected to match %s, got:%q", tt.expected, id)
+			}
+			if got := res.Header().Get(tt.options.Header); got != id {
+				t.Errorf("response header expected:%q, got:%q", id, got)
+			}
+		})
+	}
+}
+
+func TestRequestIDGenerators(t *testing.T) {
+	first, firstULID := middleware.NewUUIDv7(), middleware.NewULID()
+	time.Sleep(2 * time.Millisecond)
+	second, secondULID := middleware.NewUUIDv7(), middleware.NewULID()
+
+	if !uuidv7.MatchString(first) || !uuidv7.MatchString(second) || first >= second {
+		t.Errorf("ordered UUIDs v7 expected, got:%q %q", first, second)
+	}
+	if !ulid.MatchString(firstULID) || !ulid.MatchString(secondULID) || firstULID >= secondULID {
+		t.Errorf("ordered ULIDs expected, got:%q %q", firstULID, secondULID)
+	}
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request-ID")))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &middleware.RequestIDTransport{}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		header   string
+		expected string
+	}{
+		{"propagated", middleware.WithRequestID(context.Background(), "req-42"), "", "req-42"},
+		{"kept", middleware.WithRequestID(context.Background(), "req-42"), "req-1", "req-1"},
+		{"without request ID", context.Background(), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(tt.ctx, "GET", server.URL, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+			if string(body) != tt.expected {
+				t.Errorf("request ID expected:%q, got:%q", tt.expected, body)
+			}
+			if tt.header == "" && req.Header.Get("X-Request-ID") != "" {
+				t.Errorf("original request expected to be unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotateOptions defines when a RotatingFile is rotated.
+type RotateOptions struct {
+	// MaxSize is the maximum size of the file, in bytes (no limit if 0).
+	// A write is never split: the file is rotated before the write exceeding the size.
+	MaxSize int64
+	// Interval is the maximum age of the file (no limit if 0). The file is rotated at
+	// the first write after a multiple of the interval since the zero time, in UTC
+	// (e.g. every day at midnight UTC with 24 * time.Hour).
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept (all the rotated files if 0).
+	MaxBackups int
+	// TimeProvider is the clock of the time rotation (MockTimeProvider if nil).
+	TimeProvider TimeProvider
+}
+
+// RotatingFile is a log file rotated by size or by time, safe for concurrent use.
+//
+// When rotated, the file is renamed with the ".1" suffix, the previous rotated files
+// are shifted (".1" to ".2", and so on), and a new file is created.
+type RotatingFile struct {
+	path     string
+	options  RotateOptions
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+}
+
+// NewRotatingFile opens the log file, in append mode, or creates it.
+func NewRotatingFile(path string, options RotateOptions) (*RotatingFile, error) {
+	if options.MaxSize < 0 || options.Interval < 0 || options.MaxBackups < 0 {
+		return nil, errors.New("invalid rotate options")
+	}
+	if options.TimeProvider == nil {
+		options.TimeProvider = MockTimeProvider{}
+	}
+	f := &RotatingFile{path: path, options: options}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes the data to the file, after rotating it if needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	bySize := f.options.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.options.MaxSize
+	byTime := f.options.Interval > 0 && !f.options.TimeProvider.Now().Before(f.rotateAt)
+	if bySize || byTime {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file, and computes the time of the next rotation.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.options.Interval > 0 {
+		now := f.options.TimeProvider.Now().UTC()
+		f.rotateAt = now.Truncate(f.options.Interval).Add(f.options.Interval)
+	}
+	return nil
+}
+
+// rotate closes the file, shifts the rotated files, and opens a new file.
+// The file is reopened even if the rotated files cannot be shifted.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.shift()
+	}
+	return errors.Join(err, f.open())
+}
+
+// shift renames the file, and the previous rotated files, with the next suffix.
+func (f *RotatingFile) shift() error {
+	last := f.options.MaxBackups
+	if last == 0 {
+		// shift all the rotated files
+		for last = 1; exists(f.backup(last)); last++ {
+		}
+	} else if err := os.Remove(f.backup(last)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := last - 1; i >= 1; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(f.path, f.backup(1))
+}
+
+// backup returns the path of the i-th rotated file.
+func (f *RotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+package middleware_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/middleware"
+)
+
+// clock is a time provider whose time is set by the tests.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := middleware.NewRotatingFile(path, middleware.RotateOptions{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n", "a very long line\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, expected := range map[string]string{
+		path:        "a very long line\n",
+		path + ".1": "line 4\n",
+		path + ".2": "line 3\n",
+	} {
+		if got := readFile(t, path); got != expected {
+			t.Errorf("%s expected:%q, got:%q", filepath.Base(path), expected, got)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 expected to be removed", filepath.Base(path))
+	}
+}
+
+func TestRotatingFileByTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	os.WriteFile(path, []byte("previous\n"), 0o644)
+	c := &clock{now: time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC)}
+	f, err := middleware.NewRotatingFile(path, middleware.RotateOptions{Interval: 24 * time.Hour, TimeProvider: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Write([]byte("day 1\n"))
+	c.now = c.now.Add(2 * time.Hour)
+	f.Write([]byte("day 2\n"))
+	c.now = c.now.Add(time.Hour)
+	f.Write([]byte("day 2 again\n"))
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("closed\n")); err == nil {
+		t.Errorf("error expected after close")
+	}
+
+	for path, expected := range map[string]string{
+		path:        "",
+		path + ".1": "day 2\nday 2 again\n",
+		path + ".2": "previous\nday 1\n",
+	} {
+		if got := readFile(t, path); got != expected {
+			t.Errorf("%s expected:%q, got:%q", filepath.Base(path), expected, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TerminalHandler is a slog.Handler writing human-readable records, e.g. to a terminal.
+//
+// The request records (see NewStructuredLogger) are written on two lines, with the
+// status code in color if enabled:
+//
+//	[GET] "/status/200" (1.5ms)
+//	200 OK
+//
+// The other records are written on one line, with their attributes as key=value pairs.
+type TerminalHandler struct {
+	w      io.Writer
+	color  bool
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+	prefix string // prefix of the keys of the groups
+}
+
+// NewTerminalHandler creates a TerminalHandler writing to w, for the records
+// of the Info level and above.
+func NewTerminalHandler(w io.Writer, color bool) *TerminalHandler {
+	return &TerminalHandler{w: w, color: color, mu: &sync.Mutex{}}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *TerminalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *TerminalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], h.qualify(attrs)...)
+	return &h2
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *TerminalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// Handle implements the slog.Handler interface.
+func (h *TerminalHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := h.attrs[:len(h.attrs):len(h.attrs)]
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.qualify([]slog.Attr{a})...)
+		return true
+	})
+
+	var b strings.Builder
+	if line, ok := h.request(r.Message, attrs); ok {
+		b.WriteString(line)
+	} else {
+		b.WriteString(r.Level.String())
+		b.WriteString(" ")
+		b.WriteString(r.Message)
+		for _, a := range attrs {
+			writeAttr(&b, "", a)
+		}
+		b.WriteString("\n")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// request returns the lines of a request record.
+func (h *TerminalHandler) request(msg string, attrs []slog.Attr) (string, bool) {
+	if msg != "request" {
+		return "", false
+	}
+	values := make(map[string]slog.Value)
+	for _, a := range attrs {
+		values[a.Key] = a.Value.Resolve()
+	}
+	method, url, duration, status := values["method"], values["url"], values["duration"], values["status"]
+	if method.Kind() != slog.KindString || url.Kind() != slog.KindString ||
+		duration.Kind() != slog.KindDuration || status.Kind() != slog.KindInt64 {
+		return "", false
+	}
+
+	statusCode := int(status.Int64())
+	var colorSeq, resetSeq string
+	if h.color {
+		// https://zetcode.com/golang/terminal-colour/
+		switch {
+		case statusCode >= 100 && statusCode <= 199:
+			// 1xx informational response
+			colorSeq = "\033[34m" // blue
+		case statusCode >= 200 && statusCode <= 299:
+			// 2xx success
+			colorSeq = "\033[32m" // green
+		case statusCode >= 300 && statusCode <= 399:
+			// 3xx redirection
+			colorSeq = "\033[33m" // yellow
+		case statusCode >= 400 && statusCode <= 499:
+			// 4xx client errors
+			colorSeq = "\033[31m" // red
+		case statusCode >= 500 && statusCode <= 599:
+			// 5xx server errors
+			colorSeq = "\033[35m" // purple
+		}
+		resetSeq = "\033[0m" // reset color
+	}
+	msg = fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode))
+	return fmt.Sprintf("[%s] %q (%v)\n%s%s%s\n", method.String(), url.String(), duration.Duration(), colorSeq, msg, resetSeq), true
+}
+
+// qualify returns the attributes with the keys prefixed by the groups of the handler.
+func (h *TerminalHandler) qualify(attrs []slog.Attr) []slog.Attr {
+	if h.prefix == "" {
+		return attrs
+	}
+	qualified := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		qualified[i] = slog.Attr{Key: h.prefix + a.Key, Value: a.Value}
+	}
+	return qualified
+}
+
+// writeAttr writes the attribute as key=value pairs (one per attribute of a group).
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = strconv.Quote(s)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, s)
+}
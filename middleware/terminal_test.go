@@ -0,0 +1,58 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/middleware"
+)
+
+func TestTerminalHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		color       bool
+		log         func(logger *slog.Logger)
+		expectedLog string
+	}{
+		{
+			name:  "request with color",
+			color: true,
+			log: func(logger *slog.Logger) {
+				logger.Warn("request", "method", "POST", "url", "/items", "status", 404, "duration", 3*time.Millisecond, "bytes", 10)
+			},
+			expectedLog: "[POST] \"/items\" (3ms)\n\x1b[31m404 Not Found\x1b[0m\n",
+		},
+		{
+			name: "other record",
+			log: func(logger *slog.Logger) {
+				logger.Info("server listening", "addr", "localhost:8080", "tls", false, "name", "my server")
+			},
+			expectedLog: "INFO server listening addr=localhost:8080 tls=false name=\"my server\"\n",
+		},
+		{
+			name: "attributes and groups",
+			log: func(logger *slog.Logger) {
+				logger.With("service", "api").WithGroup("db").Error("query failed", slog.Group("query", "table", "items"), "retries", 3)
+			},
+			expectedLog: "ERROR query failed service=api db.query.table=items db.retries=3\n",
+		},
+		{
+			name: "debug record",
+			log: func(logger *slog.Logger) {
+				logger.Debug("ignored")
+			},
+			expectedLog: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(middleware.NewTerminalHandler(&buf, tt.color)))
+			if log := buf.String(); log != tt.expectedLog {
+				t.Errorf("log expected:%q, got:%q", tt.expectedLog, log)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WrapResponseWriter is a response writer recording the state of the response,
+// used by the middlewares of the package.
+//
+// It implements the optional interfaces of the wrapped response writer:
+// http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher. The other
+// interfaces are available with http.ResponseController (see Unwrap).
+type WrapResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the status code of the response, or 0 if no header has been
+	// written yet. Before the final header, it is the last informational (1xx)
+	// status code written.
+	Status() int
+	// BytesWritten returns the number of bytes of the response body written so far.
+	BytesWritten() int64
+	// WroteHeader reports whether the final header of the response has been written
+	// (explicitly, or implicitly by the first write or flush). The informational
+	// headers (1xx), except 101 Switching Protocols, are not final.
+	WroteHeader() bool
+	// FirstByteTime returns the time the final header of the response has been written,
+	// or the zero time.
+	FirstByteTime() time.Time
+	// Unwrap returns the wrapped response writer (see http.ResponseController).
+	Unwrap() http.ResponseWriter
+}
+
+// NewWrapResponseWriter wraps the response writer. The time provider gives
+// the first byte time.
+//
+// Example:
+//
+//	ww := middleware.NewWrapResponseWriter(w, middleware.MockTimeProvider{})
+//	next.ServeHTTP(ww, r)
+//	if !ww.WroteHeader() {
+//		...
+//	}
+func NewWrapResponseWriter(w http.ResponseWriter, tp TimeProvider) WrapResponseWriter {
+	b := &basicWriter{ResponseWriter: w, tp: tp}
+	var features int
+	if _, ok := w.(http.Flusher); ok {
+		features |= 1
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		features |= 2
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		features |= 4
+	}
+	if _, ok := w.(http.Pusher); ok {
+		features |= 8
+	}
+	fl, hj, rf, ps := flushWriter{b}, hijackWriter{b}, readFromWriter{b}, pushWriter{b}
+	switch features {
+	case 1:
+		return struct {
+			*basicWriter
+			flushWriter
+		}{b, fl}
+	case 2:
+		return struct {
+			*basicWriter
+			hijackWriter
+		}{b, hj}
+	case 3:
+		return struct {
+			*basicWriter
+			flushWriter
+			hijackWriter
+		}{b, fl, hj}
+	case 4:
+		return struct {
+			*basicWriter
+			readFromWriter
+		}{b, rf}
+	case 5:
+		return struct {
+			*basicWriter
+			flushWriter
+			readFromWriter
+		}{b, fl, rf}
+	case 6:
+		return struct {
+			*basicWriter
+			hijackWriter
+			readFromWriter
+		}{b, hj, rf}
+	case 7:
+		return struct {
+			*basicWriter
+			flushWriter
+			hijackWriter
+			readFromWriter
+		}{b, fl, hj, rf}
+	case 8:
+		return struct {
+			*basicWriter
+			pushWriter
+		}{b, ps}
+	case 9:
+		return struct {
+			*basicWriter
+			flushWriter
+			pushWriter
+		}{b, fl, ps}
+	case 10:
+		return struct {
+			*basicWriter
+			hijackWriter
+			pushWriter
+		}{b, hj, ps}
+	case 11:
+		return struct {
+			*basicWriter
+			flushWriter
+			hijackWriter
+			pushWriter
+		}{b, fl, hj, ps}
+	case 12:
+		return struct {
+			*basicWriter
+			readFromWriter
+			pushWriter
+		}{b, rf, ps}
+	case 13:
+		return struct {
+			*basicWriter
+			flushWriter
+			readFromWriter
+			pushWriter
+		}{b, fl, rf, ps}
+	case 14:
+		return struct {
+			*basicWriter
+			hijackWriter
+			readFromWriter
+			pushWriter
+		}{b, hj, rf, ps}
+	case 15:
+		return struct {
+			*basicWriter
+			flushWriter
+			hijackWriter
+			readFromWriter
+			pushWriter
+		}{b, fl, hj, rf, ps}
+	}
+	return b
+}
+
+// basicWriter implements WrapResponseWriter, without the optional interfaces.
+type basicWriter struct {
+	http.ResponseWriter
+	tp          TimeProvider
+	status      int
+	wroteHeader bool
+	bytes       int64
+	firstByte   time.Time
+}
+
+func (b *basicWriter) WriteHeader(code int) {
+	if !b.wroteHeader {
+		if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+			b.status = code
+		} else {
+			b.writeHeader(code)
+		}
+	}
+	b.ResponseWriter.WriteHeader(code)
+}
+
+// writeHeader records the final status code of the response.
+func (b *basicWriter) writeHeader(code int) {
+	b.status = code
+	b.wroteHeader = true
+	b.firstByte = b.tp.Now()
+}
+
+func (b *basicWriter) Write(p []byte) (int, error) {
+	if !b.WroteHeader() {
+		b.writeHeader(http.StatusOK)
+	}
+	n, err := b.ResponseWriter.Write(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
+func (b *basicWriter) Status() int {
+	return b.status
+}
+
+func (b *basicWriter) BytesWritten() int64 {
+	return b.bytes
+}
+
+func (b *basicWriter) WroteHeader() bool {
+	return b.wroteHeader
+}
+
+func (b *basicWriter) FirstByteTime() time.Time {
+	return b.firstByte
+}
+
+func (b *basicWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+type flushWriter struct {
+	b *basicWriter
+}
+
+// Flush sends the buffered data to the client (e.g. for server-sent events).
+func (f flushWriter) Flush() {
+	if !f.b.WroteHeader() {
+		f.b.writeHeader(http.StatusOK)
+	}
+	f.b.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijackWriter struct {
+	b *basicWriter
+}
+
+// Hijack lets the handler take over the connection (e.g. for a WebSocket),
+// with 101 Switching Protocols as recorded status code.
+func (h hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := h.b.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !h.b.WroteHeader() {
+		h.b.writeHeader(http.StatusSwitchingProtocols)
+	}
+	return conn, brw, err
+}
+
+type readFromWriter struct {
+	b *basicWriter
+}
+
+// ReadFrom copies the reader to the response (e.g. with sendfile).
+func (r readFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !r.b.WroteHeader() {
+		r.b.writeHeader(http.StatusOK)
+	}
+	n, err := r.b.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.b.bytes += n
+	return n, err
+}
+
+type pushWriter struct {
+	b *basicWriter
+}
+
+// Push initiates an HTTP/2 server push.
+func (p pushWriter) Push(target string, opts *http.PushOptions) error {
+	return p.b.ResponseWriter.(http.Pusher).Push(target, opts)
+}
@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/middleware"
+)
+
+// fullWriter implements all the optional interfaces of a response writer.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (w *fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func (w *fullWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+func TestWrapResponseWriterInterfaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		w        http.ResponseWriter
+		expected [4]bool // Flusher, Hijacker, ReaderFrom, Pusher
+	}{
+		{"basic", struct{ http.ResponseWriter }{httptest.NewRecorder()}, [4]bool{false, false, false, false}},
+		{"recorder", httptest.NewRecorder(), [4]bool{true, false, false, false}},
+		{"full", &fullWriter{ResponseRecorder: httptest.NewRecorder()}, [4]bool{true, true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ww := middleware.NewWrapResponseWriter(tt.w, middleware.FakeTimeProvider{})
+			_, fl := ww.(http.Flusher)
+			_, hj := ww.(http.Hijacker)
+			_, rf := ww.(io.ReaderFrom)
+			_, ps := ww.(http.Pusher)
+			if got := [4]bool{fl, hj, rf, ps}; got != tt.expected {
+				t.Errorf("interfaces expected:%v, got:%v", tt.expected, got)
+			}
+			if ww.Unwrap() != tt.w {
+				t.Errorf("unwrapped writer expected to be the wrapped writer")
+			}
+		})
+	}
+
+	// HTTP/1.1 server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, middleware.FakeTimeProvider{})
+		_, fl := ww.(http.Flusher)
+		_, hj := ww.(http.Hijacker)
+		_, rf := ww.(io.ReaderFrom)
+		_, ps := ww.(http.Pusher)
+		if got, expected := [4]bool{fl, hj, rf, ps}, [4]bool{true, true, true, false}; got != expected {
+			t.Errorf("interfaces expected:%v, got:%v", expected, got)
+		}
+	}))
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestWrapResponseWriterState(t *testing.T) {
+	fw := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	ww := middleware.NewWrapResponseWriter(fw, middleware.FakeTimeProvider{})
+	if ww.Status() != 0 || ww.WroteHeader() || !ww.FirstByteTime().IsZero() {
+		t.Errorf("empty state expected, got: %d %v %v", ww.Status(), ww.WroteHeader(), ww.FirstByteTime())
+	}
+
+	ww.Write([]byte("hello "))
+	ww.(io.ReaderFrom).ReadFrom(strings.NewReader("world"))
+	ww.WriteHeader(http.StatusInternalServerError) // superfluous
+	ww.(http.Pusher).Push("/style.css", nil)
+	ww.(http.Flusher).Flush()
+
+	if ww.Status() != http.StatusOK || !ww.WroteHeader() {
+		t.Errorf("status expected:%d, got:%d (%v)", http.StatusOK, ww.Status(), ww.WroteHeader())
+	}
+	if ww.BytesWritten() != 11 || fw.Body.String() != "hello world" {
+		t.Errorf("bytes written expected:11, got:%d (%q)", ww.BytesWritten(), fw.Body.String())
+	}
+	if expected := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC); !ww.FirstByteTime().Equal(expected) {
+		t.Errorf("first byte time expected:%v, got:%v", expected, ww.FirstByteTime())
+	}
+	if len(fw.pushed) != 1 || !fw.Flushed {
+		t.Errorf("push and flush expected to be forwarded")
+	}
+
+	// an informational header is not final
+	ww = middleware.NewWrapResponseWriter(httptest.NewRecorder(), middleware.FakeTimeProvider{})
+	ww.WriteHeader(http.StatusEarlyHints)
+	if ww.Status() != http.StatusEarlyHints || ww.WroteHeader() {
+		t.Errorf("informational state expected, got: %d %v", ww.Status(), ww.WroteHeader())
+	}
+}
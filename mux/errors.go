@@ -0,0 +1,135 @@
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HandlerFunc is an HTTP handler returning an error.
+// The error is rendered by the error handler of the router (see Mux.ErrorHandler).
+// The handler must not write the response before returning an error.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// ErrorHandlerFunc renders the error returned by a HandlerFunc.
+type ErrorHandlerFunc func(http.ResponseWriter, *http.Request, error)
+
+// HTTPError is an error with an HTTP status code and a public message.
+// The cause is internal: it is never rendered by RenderError.
+type HTTPError struct {
+	Status  int
+	Message string
+	Cause   error
+}
+
+// NewHTTPError creates a new HTTPError.
+// The message is the status text if empty.
+func NewHTTPError(status int, message string, cause error) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: message, Cause: cause}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%d %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Cause)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// StatusCode implements the StatusCoder interface.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// ThenE sets the final handler for a route using a HandlerFunc.
+func (r *route) ThenE(h HandlerFunc) error {
+	return r.Then(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := h(w, req); err != nil {
+			r.m.table().errorHandler(w, req, err)
+		}
+	}))
+}
+
+// ErrorHandler sets the handler rendering the errors returned by the HandlerFunc
+// routes (RenderError by default).
+func (m *Mux) ErrorHandler(h ErrorHandlerFunc) *Mux {
+	if h == nil {
+		panic("handler must not be nil")
+	}
+	m.update(func(t *table) { t.errorHandler = h })
+	return m
+}
+
+// RenderError renders the error, as plain text, JSON ({"error": message}) or
+// problem details (RFC 9457), depending on the Accept header of the request.
+//
+// The status code is 500 Internal Server Error, unless the error (or one of the
+// errors it wraps) implements StatusCoder. The message is the message of the
+// HTTPError, the status text for the other 5xx errors, and the error text otherwise.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	message := errorMessage(err, status)
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	switch negotiateError(r) {
+	case "application/problem+json":
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			Type   string `json:"type"`
+			Title  string `json:"title"`
+			Status int    `json:"status"`
+			Detail string `json:"detail,omitempty"`
+		}{"about:blank", http.StatusText(status), status, message})
+	case "application/json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, message)
+	}
+}
+
+// errorStatus returns the status code of the error: 500 Internal Server Error,
+// unless the error (or one of the errors it wraps) implements StatusCoder.
+func errorStatus(err error) int {
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// errorMessage returns the public message of the error: the message of the
+// HTTPError, the status text for the other 5xx errors, and the error text otherwise.
+func errorMessage(err error, status int) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Message
+	}
+	if status >= 500 {
+		return http.StatusText(status)
+	}
+	return err.Error()
+}
+
+// negotiateError returns the media type of the error response:
+// the first supported media type of the Accept header, or text/plain.
+func negotiateError(r *http.Request) string {
+	for _, mediaRange := range parseAccept(r.Header.Values("Accept")) {
+		switch mediaRange {
+		case "application/problem+json", "application/json", "text/plain":
+			return mediaRange
+		}
+	}
+	return "text/plain"
+}
@@ -0,0 +1,88 @@
+package mux_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestThenE(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/ok").ThenE(func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("ok"))
+		return nil
+	})
+	router.GET("/http").ThenE(func(w http.ResponseWriter, r *http.Request) error {
+		return mux.NewHTTPError(http.StatusNotFound, "user not found", errors.New("sql: no rows"))
+	})
+	router.GET("/wrapped").ThenE(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("wrapped: %w", mux.NewHTTPError(http.StatusConflict, "", nil))
+	})
+	router.GET("/internal").ThenE(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database password leaked")
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		code        int
+		contentType string
+		want        string
+	}{
+		{"no error", "/ok", "", http.StatusOK, "text/plain; charset=utf-8", "ok"},
+		{"plain text", "/http", "", http.StatusNotFound, "text/plain; charset=utf-8", "user not found\n"},
+		{"json", "/http", "application/json", http.StatusNotFound, "application/json", `{"error":"user not found"}` + "\n"},
+		{"problem", "/http", "application/problem+json, application/json", http.StatusNotFound, "application/problem+json",
+			`{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found"}` + "\n"},
+		{"unsupported accept", "/http", "text/html, */*", http.StatusNotFound, "text/plain; charset=utf-8", "user not found\n"},
+		{"wrapped", "/wrapped", "", http.StatusConflict, "text/plain; charset=utf-8", "Conflict\n"},
+		{"internal", "/internal", "application/json", http.StatusInternalServerError, "application/json", `{"error":"Internal Server Error"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("content type expected: %q, got: %q", tt.contentType, got)
+			}
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	var got error
+	router := mux.NewRouter()
+	router.GET("/").ThenE(func(w http.ResponseWriter, r *http.Request) error {
+		return mux.NewHTTPError(http.StatusBadRequest, "bad", errors.New("cause"))
+	})
+	router.ErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusTeapot {
+		t.Errorf("status code expected: %d, got: %d", http.StatusTeapot, res.Code)
+	}
+	if want := "400 bad: cause"; got == nil || got.Error() != want {
+		t.Errorf("error expected: %q, got: %v", want, got)
+	}
+}
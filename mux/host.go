@@ -0,0 +1,129 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Host creates a new sub-router whose routes only match the given host.
+// The host is matched case-insensitively, without the port.
+// A label of the host can be a wildcard, whose value is available
+// as a path parameter.
+//
+// The literal hosts are part of the ServeMux patterns of the routes
+// (e.g. "GET api.example.com/users"), which take precedence over the patterns
+// without host. The requests not matching a wildcard host are dispatched to the
+// next most specific pattern, like for the other matchers.
+//
+// Example:
+//
+//	tenants := router.Host("{tenant}.example.com")
+//	tenants.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+//		fmt.Fprintf(w, "tenant: %s", r.PathValue("tenant"))
+//	})
+func (m *Mux) Host(host string) *Mux {
+	match := matchHost(host)
+	sub := m.NewSubRouter("")
+	sub.host = host
+	if !literalHost(host) {
+		sub.matchers = append(sub.matchers, matcher{match, http.StatusNotFound, "host " + strings.ToLower(host)})
+	}
+	return sub
+}
+
+// literalHost reports whether the host pattern has no wildcard.
+func literalHost(host string) bool {
+	return !strings.Contains(host, "{")
+}
+
+// foldHost returns the request with a lowercase host without trailing dot,
+// matching the literal hosts of the ServeMux patterns.
+// The original host is restored by the entry matching the request (see entry.restore).
+func foldHost(r *http.Request) *http.Request {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host, port = r.Host, ""
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	if host == r.Host {
+		return r
+	}
+	original := r.Host
+	r = r.WithContext(context.WithValue(r.Context(), hostContextKey, original))
+	r.Host = host
+	return r
+}
+
+// Scheme creates a new sub-router whose routes only match the given URL scheme
+// (e.g. "https"). The scheme of a request is "https" if the connection uses TLS,
+// and "http" otherwise.
+func (m *Mux) Scheme(scheme string) *Mux {
+	scheme = strings.ToLower(scheme)
+	sub := m.NewSubRouter("")
+	sub.scheme = scheme
+	sub.matchers = append(sub.matchers, matcher{func(r *http.Request) bool {
+		return requestScheme(r) == scheme
+	}, http.StatusNotFound, "scheme " + scheme})
+	return sub
+}
+
+// matchHost returns a match function for the host pattern.
+func matchHost(pattern string) func(*http.Request) bool {
+	if pattern == "" {
+		panic("host must not be empty")
+	}
+	labels := strings.Split(pattern, ".")
+	wildcards := make([]string, len(labels))
+	for i, label := range labels {
+		if name, ok := strings.CutPrefix(label, "{"); ok {
+			name, ok = strings.CutSuffix(name, "}")
+			if !ok || name == "" {
+				panic(fmt.Sprintf("bad wildcard in host: '%s'", label))
+			}
+			wildcards[i] = name
+		} else if strings.ContainsAny(label, "{}") || label == "" {
+			panic(fmt.Sprintf("bad label in host: '%s'", label))
+		} else {
+			labels[i] = strings.ToLower(label)
+		}
+	}
+
+	return func(r *http.Request) bool {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		parts := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+		if len(parts) != len(labels) {
+			return false
+		}
+		for i, part := range parts {
+			if wildcards[i] == "" && part != labels[i] || part == "" {
+				return false
+			}
+		}
+		for i, name := range wildcards {
+			if name != "" {
+				r.SetPathValue(name, parts[i])
+			}
+		}
+		return true
+	}
+}
+
+// requestScheme returns the URL scheme of the request.
+func requestScheme(r *http.Request) string {
+	if r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
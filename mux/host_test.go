@@ -0,0 +1,215 @@
+package mux_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestHost(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("default"))
+	})
+	router.Host("api.example.com").GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api"))
+	})
+	tenants := router.Host("{tenant}.example.com")
+	tenants.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "tenant %s", r.PathValue("tenant"))
+	})
+	tenants.NewSubRouter("/users").GET("/{id}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "tenant %s, user %s", r.PathValue("tenant"), r.PathValue("id"))
+	})
+
+	tests := []struct {
+		host string
+		path string
+		code int
+		want string
+	}{
+		{"localhost", "/", http.StatusOK, "default"},
+		{"api.example.com", "/", http.StatusOK, "api"},
+		{"API.Example.com:8080", "/", http.StatusOK, "api"},
+		{"acme.example.com", "/", http.StatusOK, "tenant acme"},
+		{"acme.example.com", "/users/1", http.StatusOK, "tenant acme, user 1"},
+		// the requests not matching the host fall through to the less specific patterns
+		{"localhost", "/users/1", http.StatusOK, "default"},
+		{"a.b.example.com", "/users/1", http.StatusOK, "default"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != tt.code {
+			t.Errorf("GET %s%s: status code expected: %d, got: %d", tt.host, tt.path, tt.code, res.Code)
+		}
+		if got := res.Body.String(); got != tt.want {
+			t.Errorf("GET %s%s = %q; want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+
+	routes := router.Routes()
+	if got, want := routes[2].Host, "{tenant}.example.com"; got != want {
+		t.Errorf("host expected: %q, got: %q", want, got)
+	}
+}
+
+func TestHostsFallThrough(t *testing.T) {
+	router := mux.NewRouter()
+	router.Host("a.example.com").GET("/{slug}").ThenFunc(reply("a slug"))
+	router.Host("b.example.com").GET("/about").ThenFunc(reply("b about"))
+	router.Host("{tenant}.c.example.com").GET("/{slug}").ThenFunc(reply("c slug"))
+	router.Host("{tenant}.d.example.com").GET("/about").ThenFunc(reply("d about"))
+	files := fstest.MapFS{"index.html": {Data: []byte("static")}}
+	if err := router.Host("static.example.com").Static("/", files, mux.StaticOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	router.Host("api.example.com").GET("/status").ThenFunc(reply("api status"))
+
+	tests := []struct {
+		host string
+		path string
+		code int
+		want string
+	}{
+		{"a.example.com", "/about", http.StatusOK, "a slug"},
+		{"A.Example.com.:8080", "/about", http.StatusOK, "a slug"},
+		{"b.example.com", "/about", http.StatusOK, "b about"},
+		{"b.example.com", "/contact", http.StatusNotFound, "404 page not found\n"},
+		{"x.c.example.com", "/about", http.StatusOK, "c slug"},
+		{"x.d.example.com", "/about", http.StatusOK, "d about"},
+		{"static.example.com", "/status", http.StatusNotFound, "404 page not found\n"},
+		{"static.example.com", "/", http.StatusOK, "static"},
+		{"api.example.com", "/status", http.StatusOK, "api status"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != tt.code {
+			t.Errorf("GET %s%s: status code expected: %d, got: %d", tt.host, tt.path, tt.code, res.Code)
+		}
+		if got := res.Body.String(); got != tt.want {
+			t.Errorf("GET %s%s = %q; want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+
+	// the handlers receive the original host
+	router.Host("echo.example.com").GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "Echo.Example.com:8080"
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Body.String(); got != req.Host {
+		t.Errorf("host expected: %q, got: %q", req.Host, got)
+	}
+}
+
+func TestHostPanic(t *testing.T) {
+	for _, host := range []string{"", "{}.example.com", "a..com", "{a.com", "x{a}.com"} {
+		t.Run(host, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("the code did not panic (%q)", host)
+				}
+			}()
+			mux.NewRouter().Host(host)
+		})
+	}
+}
+
+func TestScheme(t *testing.T) {
+	router := mux.NewRouter()
+	router.Scheme("HTTPS").GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	})
+	router.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("insecure"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got, want := res.Body.String(), "insecure"; got != want {
+		t.Errorf("GET http:// = %q; want %q", got, want)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got, want := res.Body.String(), "secure"; got != want {
+		t.Errorf("GET https:// = %q; want %q", got, want)
+	}
+}
+
+func TestDuplicatedRoute(t *testing.T) {
+	defer func() {
+		want := "route already registered: 'GET /'"
+		if r := recover(); r == nil {
+			t.Errorf("the code did not panic")
+		} else if msg := r.(string); msg != want {
+			t.Errorf("panic message expected: '%s', got: '%s'", want, msg)
+		}
+	}()
+
+	router := mux.NewRouter()
+	router.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestDuplicatedMatchers(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name  string
+		build func(router *mux.Mux)
+		want  string
+	}{
+		{"host", func(router *mux.Mux) {
+			router.Host("a.com").GET("/").Then(h)
+			router.Host("A.com").GET("/").Then(h)
+		}, "route already registered: 'GET a.com/'"},
+		{"wildcard host", func(router *mux.Mux) {
+			router.Host("{tenant}.com").GET("/").Then(h)
+			router.Host("{tenant}.com").GET("/").Then(h)
+		}, "route already registered: 'GET /'"},
+		{"matchers in any order", func(router *mux.Mux) {
+			router.GET("/items").Headers("X-Api", "v1").Queries("debug", "1").Then(h)
+			router.GET("/items").Queries("debug", "1").Headers("X-Api", "v1").Then(h)
+		}, "route already registered: 'GET /items'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("the code did not panic")
+				} else if msg := r.(string); msg != tt.want {
+					t.Errorf("panic message expected: '%s', got: '%s'", tt.want, msg)
+				}
+			}()
+			tt.build(mux.NewRouter())
+		})
+	}
+
+	// the routes with different matchers are registered
+	router := mux.NewRouter()
+	router.Host("{tenant}.com").GET("/").Then(h)
+	router.Host("{tenant}.com").GET("/").Headers("X-Api", "v1").Then(h)
+	router.Host("{tenant}.org").GET("/").Then(h)
+	if routes := router.Routes(); len(routes) != 3 {
+		t.Errorf("3 routes expected, got: %d", len(routes))
+	}
+}
@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Hub broadcasts messages to the WebSocket connections of rooms.
+// The connections leave the rooms when they are closed.
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*WebSocket]bool
+}
+
+// NewHub creates a new hub.
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[string]map[*WebSocket]bool)}
+}
+
+// Join adds the connection to the room.
+func (h *Hub) Join(room string, ws *WebSocket) {
+	h.mu.Lock()
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*WebSocket]bool)
+	}
+	h.rooms[room][ws] = true
+	h.mu.Unlock()
+
+	ws.hubsMu.Lock()
+	if ws.hubs == nil {
+		ws.hubs = make(map[*Hub]map[string]bool)
+	}
+	if ws.hubs[h] == nil {
+		ws.hubs[h] = make(map[string]bool)
+	}
+	ws.hubs[h][room] = true
+	ws.hubsMu.Unlock()
+}
+
+// Leave removes the connection from the room.
+func (h *Hub) Leave(room string, ws *WebSocket) {
+	h.leave(room, ws)
+	ws.hubsMu.Lock()
+	delete(ws.hubs[h], room)
+	ws.hubsMu.Unlock()
+}
+
+func (h *Hub) leave(room string, ws *WebSocket) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rooms[room], ws)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Len returns the number of connections in the room.
+func (h *Hub) Len(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast sends the message to the connections of the room, concurrently.
+// The connections failing to receive the message are closed.
+func (h *Hub) Broadcast(room string, typ MessageType, data []byte) {
+	h.mu.RLock()
+	members := make([]*WebSocket, 0, len(h.rooms[room]))
+	for ws := range h.rooms[room] {
+		members = append(members, ws)
+	}
+	h.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, ws := range members {
+		wg.Go(func() {
+			if err := ws.WriteMessage(typ, data); err != nil {
+				h.Leave(room, ws)
+				ws.conn.Close()
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// BroadcastJSON sends v encoded in JSON as a text message to the connections of the room.
+func (h *Hub) BroadcastJSON(room string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	h.Broadcast(room, TextMessage, b)
+	return nil
+}
+
+// leaveHubs removes the connection from the rooms of all the hubs.
+func (ws *WebSocket) leaveHubs() {
+	ws.hubsMu.Lock()
+	hubs := ws.hubs
+	ws.hubs = nil
+	ws.hubsMu.Unlock()
+	for h, rooms := range hubs {
+		for room := range rooms {
+			h.leave(room, ws)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mux_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestHub(t *testing.T) {
+	hub := mux.NewHub()
+	joined := make(chan struct{})
+	router := mux.NewRouter()
+	router.WebSocket("/ws/{room}").Then(mux.Upgrade(func(ctx context.Context, ws *mux.WebSocket) error {
+		room := ws.Request().PathValue("room")
+		hub.Join(room, ws)
+		joined <- struct{}{}
+		for {
+			var msg chatMessage
+			if err := ws.ReadJSON(&msg); err != nil {
+				return err
+			}
+			msg.Room = room
+			if err := hub.BroadcastJSON(room, msg); err != nil {
+				return err
+			}
+		}
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	alice := dialWS(t, server, "/ws/lobby", nil)
+	<-joined
+	bob := dialWS(t, server, "/ws/lobby", nil)
+	<-joined
+	carol := dialWS(t, server, "/ws/kitchen", nil)
+	<-joined
+	if got := hub.Len("lobby"); got != 2 {
+		t.Fatalf("lobby members expected: 2, got: %d", got)
+	}
+
+	alice.write(t, 0x1, true, []byte(`{"text":"hello"}`))
+	want := `{"room":"lobby","text":"hello"}`
+	alice.expect(t, 0x1, true, want)
+	bob.expect(t, 0x1, true, want)
+
+	carol.write(t, 0x1, true, []byte(`{"text":"alone"}`))
+	carol.expect(t, 0x1, true, `{"room":"kitchen","text":"alone"}`)
+
+	// closed connections leave their rooms
+	bob.write(t, 0x8, true, closeFrame(mux.CloseNormal, ""))
+	bob.expect(t, 0x8, true, string(closeFrame(mux.CloseNormal, "")))
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Len("lobby") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("lobby members expected: 1, got: %d", hub.Len("lobby"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,152 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// StatusCoder is implemented by the errors (and the responses) with an HTTP status code.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Validator is implemented by the requests validated after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// JSONOptions defines how the requests and the responses are handled by JSON.
+type JSONOptions struct {
+	// MaxBodySize is the maximum size of the request body, in bytes (no limit if 0).
+	MaxBodySize int64
+	// AllowUnknownFields allows the fields of the request body without matching field.
+	AllowUnknownFields bool
+	// Errors maps errors (matched with errors.Is, in order) to HTTP status codes.
+	Errors []ErrorStatus
+	// ErrorBody returns the body of an error response.
+	// The default body is {"error": message}, with the message of RenderError.
+	ErrorBody func(status int, err error) any
+}
+
+// ErrorStatus maps an error to an HTTP status code (see JSONOptions).
+type ErrorStatus struct {
+	Err    error
+	Status int
+}
+
+// DefaultJSONOptions are the options of the handlers returned by JSON.
+var DefaultJSONOptions = JSONOptions{
+	MaxBodySize: 1 << 20, // 1 MB
+}
+
+// JSON returns a handler decoding the request body into a Req, calling fn,
+// and encoding the returned Resp, with DefaultJSONOptions.
+//
+// The request is validated if it implements Validator (422 Unprocessable Entity on error).
+// The status code of the response is 200 OK, unless the response implements StatusCoder.
+// The status code of an error is 500 Internal Server Error, unless the error (or one
+// of the errors it wraps) implements StatusCoder or is mapped by the options.
+//
+// Example:
+//
+//	router.POST("/users").Use(auth).Then(mux.JSON(createUser))
+//
+//	func createUser(ctx context.Context, req CreateUserRequest) (User, error) {
+//		...
+//	}
+func JSON[Req, Resp any](fn func(context.Context, Req) (Resp, error)) http.Handler {
+	return JSONWith(DefaultJSONOptions, fn)
+}
+
+// JSONWith is like JSON, with the given options.
+func JSONWith[Req, Resp any](options JSONOptions, fn func(context.Context, Req) (Resp, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := decodeJSON(w, r, &req, options); err != nil {
+			writeJSONError(w, err.Status, err.Cause, options)
+			return
+		}
+		if v, ok := any(&req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				writeJSONError(w, http.StatusUnprocessableEntity, err, options)
+				return
+			}
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			status := errorStatus(err)
+			if i := slices.IndexFunc(options.Errors, func(e ErrorStatus) bool { return errors.Is(err, e.Err) }); i >= 0 {
+				status = options.Errors[i].Status
+			}
+			writeJSONError(w, status, err, options)
+			return
+		}
+		status := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+		writeJSON(w, status, resp)
+	})
+}
+
+// decodeJSON decodes the request body, if any.
+// The error is the status code of the response, with the decoding error as cause.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any, options JSONOptions) *HTTPError {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			return NewHTTPError(http.StatusUnsupportedMediaType, "", fmt.Errorf("unsupported content type: '%s'", contentType))
+		}
+	}
+
+	body := r.Body
+	if options.MaxBodySize > 0 {
+		body = http.MaxBytesReader(w, body, options.MaxBodySize)
+	}
+	dec := json.NewDecoder(body)
+	if !options.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, "", err)
+		}
+		return NewHTTPError(http.StatusBadRequest, "", err)
+	}
+	if dec.More() {
+		return NewHTTPError(http.StatusBadRequest, "", errors.New("unexpected data after the JSON value"))
+	}
+	return nil
+}
+
+// writeJSONError writes the error response, with the status code.
+func writeJSONError(w http.ResponseWriter, status int, err error, options JSONOptions) {
+	var body any
+	if options.ErrorBody != nil {
+		body = options.ErrorBody(status, err)
+	} else {
+		body = map[string]string{"error": errorMessage(err, status)}
+	}
+	writeJSON(w, status, body)
+}
+
+// writeJSON writes the JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		b = []byte(`{"error":"Internal Server Error"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+	w.Write([]byte("\n"))
+}
@@ -0,0 +1,137 @@
+package mux_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *greetRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("missing name")
+	}
+	return nil
+}
+
+type greetResponse struct {
+	Message string `json:"message"`
+}
+
+type createdResponse struct {
+	ID int `json:"id"`
+}
+
+func (createdResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+type teapotError struct{}
+
+func (teapotError) Error() string   { return "i'm a teapot" }
+func (teapotError) StatusCode() int { return http.StatusTeapot }
+
+var errForbidden = errors.New("forbidden name")
+
+func greet(ctx context.Context, req greetRequest) (greetResponse, error) {
+	switch req.Name {
+	case "teapot":
+		return greetResponse{}, fmt.Errorf("greet: %w", teapotError{})
+	case "root":
+		return greetResponse{}, errForbidden
+	case "ghost":
+		return greetResponse{}, mux.NewHTTPError(http.StatusNotFound, "unknown name", errors.New("sql: no rows"))
+	case "panic":
+		return greetResponse{}, errors.New("database password leaked")
+	}
+	return greetResponse{Message: "Hello " + req.Name + "!"}, nil
+}
+
+func TestJSON(t *testing.T) {
+	options := mux.DefaultJSONOptions
+	options.MaxBodySize = 32
+	options.Errors = []mux.ErrorStatus{{errForbidden, http.StatusForbidden}, {errForbidden, http.StatusConflict}}
+
+	router := mux.NewRouter()
+	router.POST("/greet").Use(trace("route")).Then(mux.JSONWith(options, greet))
+	router.POST("/create").Then(mux.JSON(func(ctx context.Context, req struct{}) (createdResponse, error) {
+		return createdResponse{ID: 42}, nil
+	}))
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		code        int
+		want        string
+	}{
+		{"success", "/greet", "application/json", `{"name":"World"}`, http.StatusOK, `{"message":"Hello World!"}` + "\n"},
+		{"no content type", "/greet", "", `{"name":"World"}`, http.StatusOK, `{"message":"Hello World!"}` + "\n"},
+		{"status coder response", "/create", "", ``, http.StatusCreated, `{"id":42}` + "\n"},
+		{"unsupported media type", "/greet", "text/plain", `name=World`, http.StatusUnsupportedMediaType, `{"error":"unsupported content type: 'text/plain'"}` + "\n"},
+		{"syntax error", "/greet", "application/json", `{"name":`, http.StatusBadRequest, `{"error":"unexpected EOF"}` + "\n"},
+		{"unknown field", "/greet", "application/json", `{"nom":"World"}`, http.StatusBadRequest, `{"error":"json: unknown field \"nom\""}` + "\n"},
+		{"trailing data", "/greet", "application/json", `{"name":"a"} {}`, http.StatusBadRequest, `{"error":"unexpected data after the JSON value"}` + "\n"},
+		{"body too large", "/greet", "application/json", `{"name":"` + strings.Repeat("a", 32) + `"}`, http.StatusRequestEntityTooLarge, `{"error":"http: request body too large"}` + "\n"},
+		{"validation", "/greet", "application/json", `{}`, http.StatusUnprocessableEntity, `{"error":"missing name"}` + "\n"},
+		{"typed error", "/greet", "application/json", `{"name":"teapot"}`, http.StatusTeapot, `{"error":"greet: i'm a teapot"}` + "\n"},
+		{"mapped error", "/greet", "application/json", `{"name":"root"}`, http.StatusForbidden, `{"error":"forbidden name"}` + "\n"},
+		{"http error", "/greet", "application/json", `{"name":"ghost"}`, http.StatusNotFound, `{"error":"unknown name"}` + "\n"},
+		{"internal error", "/greet", "application/json", `{"name":"panic"}`, http.StatusInternalServerError, `{"error":"Internal Server Error"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+			if got := res.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("content type expected: %q, got: %q", "application/json", got)
+			}
+		})
+	}
+
+	// the route middlewares still apply
+	req := httptest.NewRequest("POST", "/greet", strings.NewReader(`{"name":"World"}`))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Header().Get("X-Trace"); got != "route" {
+		t.Errorf("trace expected: %q, got: %q", "route", got)
+	}
+}
+
+func TestJSONErrorBody(t *testing.T) {
+	options := mux.DefaultJSONOptions
+	options.ErrorBody = func(status int, err error) any {
+		return map[string]any{"status": status, "detail": err.Error()}
+	}
+
+	h := mux.JSONWith(options, greet)
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	want := `{"detail":"missing name","status":422}` + "\n"
+	if got := res.Body.String(); got != want {
+		t.Errorf("response body expected: %q, got: %q", want, got)
+	}
+}
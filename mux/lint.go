@@ -0,0 +1,173 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ProblemKind is the kind of a problem reported by Validate.
+type ProblemKind int
+
+const (
+	// ProblemConflict is a route conflicting with a route registered before
+	// (the route is not registered).
+	ProblemConflict ProblemKind = iota
+	// ProblemShadowed is a route never matched: a route with the same pattern,
+	// registered before, matches every request it matches.
+	ProblemShadowed
+	// ProblemNoMethod is a route without a method, matching every method
+	// (the mount points are not reported).
+	ProblemNoMethod
+	// ProblemInvalid is an invalid route (e.g. a path not beginning with '/'),
+	// or an invalid router setting.
+	ProblemInvalid
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case ProblemConflict:
+		return "conflict"
+	case ProblemShadowed:
+		return "shadowed"
+	case ProblemNoMethod:
+		return "no method"
+	case ProblemInvalid:
+		return "invalid"
+	}
+	return fmt.Sprintf("ProblemKind(%d)", int(k))
+}
+
+// Problem is a problem of the route table reported by Validate.
+type Problem struct {
+	Kind ProblemKind
+	// Route is the route with the problem (e.g. "GET /users/{id}"),
+	// empty for a router setting.
+	Route string
+	// Message describes the problem.
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Route == "" {
+		return fmt.Sprintf("%s: %s", p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Kind, p.Route, p.Message)
+}
+
+// Validate builds the routes of a new router with build, and reports every problem
+// of the route table: the conflicts, the shadowed routes and the routes without a method.
+// It returns the router with the valid routes.
+//
+// Like with Swap, the route builders don't panic while building: every route is checked,
+// instead of stopping at the first conflict. Validate is meant for the tests and the
+// tools (see cmd/routes).
+//
+// Example:
+//
+//	func TestRoutes(t *testing.T) {
+//		if _, problems := mux.Validate(api.Routes); len(problems) > 0 {
+//			t.Errorf("invalid routes: %v", problems)
+//		}
+//	}
+func Validate(build func(*Mux)) (*Mux, []Problem) {
+	router := NewRouter()
+	t := newTable(router)
+	router.build(t, build)
+	router.tables.live.Store(t)
+
+	var problems []Problem
+	for _, err := range t.errs {
+		problems = append(problems, errorProblem(err))
+	}
+	for _, r := range t.routes {
+		if shadow := r.shadowedBy(); shadow != nil {
+			problems = append(problems, Problem{
+				Kind:    ProblemShadowed,
+				Route:   r.info.String(),
+				Message: fmt.Sprintf("shadowed by '%s', tried before with a subset of its matchers", shadow.info),
+			})
+		}
+		if r.method == "" && !r.mount {
+			problems = append(problems, Problem{
+				Kind:    ProblemNoMethod,
+				Route:   r.info.String(),
+				Message: "no method, the route matches every method",
+			})
+		}
+	}
+	return router, problems
+}
+
+// errorProblem returns the problem of an error recorded while building.
+func errorProblem(err error) Problem {
+	var re *routeError
+	if !errors.As(err, &re) {
+		return Problem{Kind: ProblemInvalid, Message: err.Error()}
+	}
+	p := Problem{Kind: ProblemInvalid, Route: strings.TrimSpace(re.route), Message: fmt.Sprint(re.v)}
+	if pattern, ok := strings.CutPrefix(p.Message, "route already registered: "); ok {
+		p.Kind = ProblemConflict
+		p.Message = fmt.Sprintf("conflicts with %s: same pattern and matchers", pattern)
+	} else if loc := conflictPattern.FindStringSubmatchIndex(p.Message); loc != nil {
+		p.Kind = ProblemConflict
+		p.Message = fmt.Sprintf("conflicts with '%s': %s", p.Message[loc[2]:loc[3]], strings.ReplaceAll(p.Message[loc[1]:], "\n", " "))
+	}
+	return p
+}
+
+// shadowedBy returns the route of the same entry, tried before the route,
+// whose matchers are a subset of the matchers of the route, or nil.
+// The matchers without key are never considered as equal.
+func (r *route) shadowedBy() *route {
+	e := r.entry()
+	if e == nil {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for _, m := range r.matchers {
+		keys[m.key] = m.key != ""
+	}
+	for _, other := range e.list() {
+		if other == r {
+			return nil
+		}
+		subset := true
+		for _, m := range other.matchers {
+			subset = subset && keys[m.key]
+		}
+		if subset {
+			return other
+		}
+	}
+	return nil
+}
+
+// entry returns the entry of the route.
+func (r *route) entry() *entry {
+	for _, e := range r.m.table().entries {
+		for _, rt := range e.list() {
+			if rt == r {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// routeError is the error of a route builder while the routes are built
+// by Swap or Validate.
+type routeError struct {
+	route string // the method (if any) and the path of the route
+	v     any    // the panic value
+}
+
+func (e *routeError) Error() string {
+	return fmt.Sprintf("route '%s': %v", e.route, e.v)
+}
+
+// conflictPattern matches the panic message of the ServeMux when a pattern conflicts
+// with another one. The message is not part of the ServeMux API: its format is
+// pinned by the tests.
+var conflictPattern = regexp.MustCompile(`conflicts with pattern "([^"]*)" \(registered at [^)]*\):\n`)
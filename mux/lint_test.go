@@ -0,0 +1,113 @@
+package mux_test
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestValidate(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router, problems := mux.Validate(func(router *mux.Mux) {
+		router.GET("/a/{x}").Then(h)
+		router.GET("/{y}/b").Then(h)
+		router.GET("/a/{z}").Then(h)
+		router.GET("/items").Headers("X-Api", "v1").Then(h)
+		router.GET("/items").Headers("X-Api", "v1").Queries("debug", "1").Then(h)
+		router.GET("/items").Then(h)
+		router.GET("/items").Then(h)
+		router.Handle("", "/any").Then(h)
+		router.Mount("/static", h)
+		router.GET("invalid").Then(h)
+		router.Host("{")
+		router.GET("/never").Then(h)
+	})
+
+	tests := []struct {
+		kind    mux.ProblemKind
+		route   string
+		message string
+	}{
+		{mux.ProblemConflict, "GET /{y}/b", "conflicts with 'GET /a/{x}': GET /{y}/b and GET /a/{x} both match some paths"},
+		{mux.ProblemConflict, "GET /a/{z}", "conflicts with 'GET /a/{x}': GET /a/{z} matches the same requests as GET /a/{x}"},
+		{mux.ProblemConflict, "GET /items", "conflicts with 'GET /items': same pattern and matchers"},
+		{mux.ProblemInvalid, "GET invalid", "path must begin with '/'"},
+		{mux.ProblemInvalid, "", "bad wildcard in host: '{'"},
+		{mux.ProblemShadowed, "GET /items", "shadowed by 'GET /items', tried before with a subset of its matchers"},
+		{mux.ProblemNoMethod, "/any", "no method, the route matches every method"},
+	}
+	if len(problems) != len(tests) {
+		t.Fatalf("%d problems expected, got: %d (%v)", len(tests), len(problems), problems)
+	}
+	for i, tt := range tests {
+		p := problems[i]
+		if p.Kind != tt.kind || p.Route != tt.route || !strings.HasPrefix(p.Message, tt.message) {
+			t.Errorf("problem expected: %s: %s: %s, got: %s", tt.kind, tt.route, tt.message, p)
+		}
+	}
+
+	// the valid routes are registered
+	if routes := router.Routes(); len(routes) != 6 {
+		t.Errorf("6 routes expected, got: %d", len(routes))
+	}
+	if code, _ := get(router, "/a/1"); code != http.StatusOK {
+		t.Errorf("GET /a/1 = %d; want %d", code, http.StatusOK)
+	}
+}
+
+// TestValidateServeMuxConflicts pins the format of the panic messages of the
+// ServeMux conflicts, parsed by Validate: a change of the format in a new Go
+// release turns the conflicts into invalid routes.
+func TestValidateServeMuxConflicts(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		first, second string
+		message       string
+	}{
+		{"/a/{x}", "/a/{z}", "/a/{z} matches the same requests as /a/{x}"},
+		{"GET /a/{x}", "GET /{y}/b", "GET /{y}/b and GET /a/{x} both match some paths"},
+		{"/admin/{path...}", "GET /{path...}", "GET /{path...} matches fewer methods than /admin/{path...}, but has a more general path pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.second, func(t *testing.T) {
+			_, problems := mux.Validate(func(router *mux.Mux) {
+				for _, pattern := range []string{tt.first, tt.second} {
+					method, path, found := strings.Cut(pattern, " ")
+					if !found {
+						method, path = "", pattern
+					}
+					router.Handle(method, path).Then(h)
+				}
+			})
+			i := slices.IndexFunc(problems, func(p mux.Problem) bool { return p.Kind != mux.ProblemNoMethod })
+			if i < 0 {
+				t.Fatalf("conflict expected, got: %v", problems)
+			}
+			p := problems[i]
+			want := "conflicts with '" + tt.first + "': " + tt.message
+			if p.Kind != mux.ProblemConflict || p.Route != tt.second || !strings.HasPrefix(p.Message, want) || strings.Contains(p.Message, "registered at") {
+				t.Errorf("problem expected: %s: %s: %s, got: %s", mux.ProblemConflict, tt.second, want, p)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutProblems(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	_, problems := mux.Validate(func(router *mux.Mux) {
+		router.GET("/items").Then(h)
+		router.GET("/items").Headers("X-Api", "v1").Produces("application/json").Then(h)
+		router.GET("/items").Headers("X-Api", "v1").Then(h)
+		router.POST("/items").Consumes("application/json").Then(h)
+		router.GET("/items/{id}").Then(h)
+		router.Host("api.example.com").GET("/items/{id}").Then(h)
+		router.Mount("/static", h)
+	})
+	if len(problems) > 0 {
+		t.Errorf("no problem expected, got: %v", problems)
+	}
+}
@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Headers adds a matcher on the request headers, as key/value pairs.
+// An empty value matches any value, as long as the header is present.
+func (r *route) Headers(pairs ...string) *route {
+	if len(pairs)%2 != 0 {
+		panic("odd number of headers")
+	}
+	r.matchers = append(r.matchers, matcher{func(req *http.Request) bool {
+		for i := 0; i < len(pairs); i += 2 {
+			values := req.Header.Values(pairs[i])
+			if len(values) == 0 || pairs[i+1] != "" && values[0] != pairs[i+1] {
+				return false
+			}
+		}
+		return true
+	}, http.StatusNotFound, fmt.Sprintf("headers %q", pairs)})
+	return r
+}
+
+// Queries adds a matcher on the query parameters, as key/value pairs.
+// An empty value matches any value, as long as the parameter is present.
+func (r *route) Queries(pairs ...string) *route {
+	if len(pairs)%2 != 0 {
+		panic("odd number of queries")
+	}
+	r.matchers = append(r.matchers, matcher{func(req *http.Request) bool {
+		query := req.URL.Query()
+		for i := 0; i < len(pairs); i += 2 {
+			if !query.Has(pairs[i]) || pairs[i+1] != "" && query.Get(pairs[i]) != pairs[i+1] {
+				return false
+			}
+		}
+		return true
+	}, http.StatusNotFound, fmt.Sprintf("queries %q", pairs)})
+	return r
+}
+
+// Consumes adds a matcher on the Content-Type header of the request.
+// A media type can be a range (e.g. "text/*").
+// If no route matches, the response is 415 Unsupported Media Type.
+func (r *route) Consumes(mediaTypes ...string) *route {
+	r.matchers = append(r.matchers, matcher{func(req *http.Request) bool {
+		contentType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+		for _, mediaType := range mediaTypes {
+			if matchMediaType(mediaType, contentType) {
+				return true
+			}
+		}
+		return false
+	}, http.StatusUnsupportedMediaType, fmt.Sprintf("consumes %q", mediaTypes)})
+	return r
+}
+
+// Produces adds a matcher on the Accept header of the request.
+// A request without Accept header accepts any media type.
+// If no route matches, the response is 406 Not Acceptable.
+func (r *route) Produces(mediaTypes ...string) *route {
+	r.matchers = append(r.matchers, matcher{func(req *http.Request) bool {
+		accept := req.Header.Values("Accept")
+		if len(accept) == 0 {
+			return true
+		}
+		for _, mediaRange := range parseAccept(accept) {
+			for _, mediaType := range mediaTypes {
+				if matchMediaType(mediaRange, mediaType) {
+					return true
+				}
+			}
+		}
+		return false
+	}, http.StatusNotAcceptable, fmt.Sprintf("produces %q", mediaTypes)})
+	return r
+}
+
+// matchMediaType reports whether the media type belongs to the media range
+// (e.g. "text/html" belongs to "text/*" and "*/*").
+func matchMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || strings.EqualFold(mediaRange, mediaType) {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && len(mediaType) > len(prefix) && strings.EqualFold(mediaType[:len(prefix)+1], prefix+"/")
+}
+
+// parseAccept returns the acceptable media ranges of the Accept header values,
+// without the ranges whose quality is 0.
+func parseAccept(values []string) []string {
+	var ranges []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			if q, found := params["q"]; found {
+				if v, err := strconv.ParseFloat(q, 64); err != nil || v <= 0 {
+					continue
+				}
+			}
+			ranges = append(ranges, mediaRange)
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,128 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestMatchers(t *testing.T) {
+	reply := func(msg string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(msg))
+		}
+	}
+
+	router := mux.NewRouter()
+	router.GET("/report").Queries("format", "csv").ThenFunc(reply("csv"))
+	router.GET("/report").ThenFunc(reply("default"))
+	router.GET("/report").Headers("X-Api-Version", "2").ThenFunc(reply("v2"))
+	router.GET("/report").Headers("X-Debug", "").ThenFunc(reply("debug"))
+
+	router.POST("/items").Consumes("application/json").ThenFunc(reply("json"))
+	router.POST("/items").Consumes("text/*").ThenFunc(reply("text"))
+
+	router.GET("/items").Produces("application/json").ThenFunc(reply("json"))
+	router.GET("/items").Produces("text/html").ThenFunc(reply("html"))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header http.Header
+		code   int
+		want   string
+	}{
+		{"query", "GET", "/report?format=csv", nil, http.StatusOK, "csv"},
+		{"header", "GET", "/report", http.Header{"X-Api-Version": {"2"}}, http.StatusOK, "v2"},
+		{"header presence", "GET", "/report", http.Header{"X-Debug": {"1"}}, http.StatusOK, "debug"},
+		{"default", "GET", "/report?format=pdf", http.Header{"X-Api-Version": {"1"}}, http.StatusOK, "default"},
+		{"consumes", "POST", "/items", http.Header{"Content-Type": {"application/json; charset=utf-8"}}, http.StatusOK, "json"},
+		{"consumes range", "POST", "/items", http.Header{"Content-Type": {"text/plain"}}, http.StatusOK, "text"},
+		{"unsupported media type", "POST", "/items", http.Header{"Content-Type": {"image/png"}}, http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"missing content type", "POST", "/items", nil, http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"produces", "GET", "/items", http.Header{"Accept": {"text/html, application/xhtml+xml"}}, http.StatusOK, "html"},
+		{"produces range", "GET", "/items", http.Header{"Accept": {"application/*"}}, http.StatusOK, "json"},
+		{"produces quality", "GET", "/items", http.Header{"Accept": {"application/json;q=0, */*;q=0.1"}}, http.StatusOK, "json"},
+		{"no accept", "GET", "/items", nil, http.StatusOK, "json"},
+		{"not acceptable", "GET", "/items", http.Header{"Accept": {"image/png"}}, http.StatusNotAcceptable, "Not Acceptable\n"},
+		{"refused", "GET", "/items", http.Header{"Accept": {"application/json;q=0"}}, http.StatusNotAcceptable, "Not Acceptable\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchersNotFound(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/report").Queries("format", "csv").ThenFunc(func(http.ResponseWriter, *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/report", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("status code expected: %d, got: %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestMatchersFallThrough(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/items/{id}").ThenFunc(reply("item"))
+	router.GET("/items/special").Headers("X-Beta", "1").ThenFunc(reply("special"))
+	router.GET("/items/new").Queries("draft", "1").ThenFunc(reply("draft"))
+	router.POST("/files/{name}").Consumes("text/*").ThenFunc(reply("text"))
+	router.POST("/files/report").Consumes("application/json").ThenFunc(reply("json"))
+	router.GET("/files/{name}").Produces("text/html").ThenFunc(reply("html"))
+	router.GET("/files/report").Produces("application/json").ThenFunc(reply("json"))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header http.Header
+		code   int
+		want   string
+	}{
+		{"header", "GET", "/items/special", http.Header{"X-Beta": {"1"}}, http.StatusOK, "special"},
+		{"header fall through", "GET", "/items/special", nil, http.StatusOK, "item"},
+		{"query fall through", "GET", "/items/new?draft=0", nil, http.StatusOK, "item"},
+		{"consumes fall through", "POST", "/files/report", http.Header{"Content-Type": {"text/plain"}}, http.StatusOK, "text"},
+		{"unsupported media type", "POST", "/files/report", http.Header{"Content-Type": {"image/png"}}, http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"produces fall through", "GET", "/files/report", http.Header{"Accept": {"text/html"}}, http.StatusOK, "html"},
+		{"not acceptable", "GET", "/files/report", http.Header{"Accept": {"image/png"}}, http.StatusNotAcceptable, "Not Acceptable\n"},
+		{"method not allowed", "DELETE", "/items/special", nil, http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount attaches the handler under the given prefix, for any method.
+// The router middlewares apply to the handler, which receives the requests
+// without the prefix in their URL path (the prefix can contain wildcards).
+// The mount point is returned by MountPoint.
+//
+// The {path...} wildcard is registered after the prefix, and the requests
+// to the prefix without trailing slash are redirected to the prefix with it.
+//
+// Example:
+//
+//	router.Mount("/static", http.FileServerFS(assets))
+//	router.Mount("/admin", adminRouter)
+func (m *Mux) Mount(prefix string, h http.Handler) error {
+	if inner, ok := h.(*Mux); ok && inner.tables == m.tables {
+		panic("cannot mount a router on itself")
+	}
+	return m.mount("", prefix, h)
+}
+
+// mount attaches the handler under the given prefix, for the given method
+// (any method if empty).
+//
+// The route is registered for any method, so that it doesn't conflict with the
+// mount points under the prefix, and the other methods are answered by the router,
+// like for the other routes (see Mux.ServeHTTP).
+func (m *Mux) mount(method string, prefix string, h http.Handler) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	segments := strings.Count(m.prefix+prefix, "/")
+	r := m.Handle("", prefix+"/{path...}")
+	r.mount = true
+	if method != "" {
+		r.only = method
+		r.matchers = append(r.matchers, matcher{
+			match: func(req *http.Request) bool {
+				return req.Method == method || method == http.MethodGet && req.Method == http.MethodHead
+			},
+			status: http.StatusMethodNotAllowed,
+			key:    "method:" + method,
+		})
+	}
+	return r.Then(stripPrefix(h, segments))
+}
+
+// MountPoint returns the path of the mount point of the request,
+// or an empty string if the request is not served by a mounted handler.
+func MountPoint(r *http.Request) string {
+	mountPoint, _ := r.Context().Value(mountContextKey).(string)
+	return mountPoint
+}
+
+// stripPrefix returns a handler serving the requests without the first segments
+// of their URL path, like http.StripPrefix.
+func stripPrefix(h http.Handler, segments int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escaped := r.URL.EscapedPath()
+		i := 0
+		for range segments {
+			j := strings.IndexByte(escaped[i+1:], '/')
+			if j < 0 {
+				http.NotFound(w, r)
+				return
+			}
+			i += j + 1
+		}
+		mountPoint, rest := escaped[:i], escaped[i:]
+		path, err := url.PathUnescape(rest)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if unescaped, err := url.PathUnescape(mountPoint); err == nil {
+			mountPoint = unescaped
+		}
+
+		ctx := context.WithValue(r.Context(), mountContextKey, MountPoint(r)+mountPoint)
+		r2 := r.WithContext(ctx)
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = rest
+		}
+		h.ServeHTTP(w, r2)
+	})
+}
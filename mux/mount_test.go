@@ -0,0 +1,87 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestMount(t *testing.T) {
+	inspect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %q %q %q", r.Method, mux.MountPoint(r), r.URL.Path, r.URL.RawPath)
+	})
+
+	admin := mux.NewRouter()
+	admin.GET("/status").ThenFunc(reply("admin status"))
+	admin.GET("/users/{id}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%q user %s", mux.MountPoint(r), r.PathValue("id"))
+	})
+
+	files := fstest.MapFS{"css/main.css": {Data: []byte("body{}")}}
+
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+	router.Mount("/inspect", inspect)
+	router.Mount("/tenants/{tenant}/inspect/", inspect)
+	router.Mount("/admin", admin)
+	router.Mount("/static", http.FileServerFS(files))
+	router.NewSubRouter("/api").Mount("/admin", admin)
+
+	tests := []struct {
+		method string
+		target string
+		code   int
+		want   string
+	}{
+		{"GET", "/inspect/a/b", http.StatusOK, `GET "/inspect" "/a/b" ""`},
+		{"POST", "/inspect/", http.StatusOK, `POST "/inspect" "/" ""`},
+		{"GET", "/inspect/a%2Fb/c", http.StatusOK, `GET "/inspect" "/a/b/c" "/a%2Fb/c"`},
+		{"GET", "/tenants/acme/inspect/x", http.StatusOK, `GET "/tenants/acme/inspect" "/x" ""`},
+		{"GET", "/admin/status", http.StatusOK, "admin status"},
+		{"GET", "/admin/users/1", http.StatusOK, `"/admin" user 1`},
+		{"GET", "/api/admin/users/2", http.StatusOK, `"/api/admin" user 2`},
+		{"GET", "/admin/unknown", http.StatusNotFound, "404 page not found\n"},
+		{"GET", "/static/css/main.css", http.StatusOK, "body{}"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != tt.code {
+			t.Errorf("%s %s: status code expected: %d, got: %d", tt.method, tt.target, tt.code, res.Code)
+		}
+		if got := res.Body.String(); got != tt.want {
+			t.Errorf("%s %s = %q; want %q", tt.method, tt.target, got, tt.want)
+		}
+		if got := res.Header().Get("X-Trace"); got != "global" {
+			t.Errorf("%s %s: trace expected: %q, got: %q", tt.method, tt.target, "global", got)
+		}
+	}
+
+	// the mount point without trailing slash is redirected
+	req := httptest.NewRequest("GET", "/admin", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Header().Get("Location"); got != "/admin/" {
+		t.Errorf("location expected: %q, got: %q", "/admin/", got)
+	}
+}
+
+func TestMountItself(t *testing.T) {
+	defer func() {
+		want := "cannot mount a router on itself"
+		if r := recover(); r == nil {
+			t.Errorf("the code did not panic")
+		} else if msg := r.(string); msg != want {
+			t.Errorf("panic message expected: '%s', got: '%s'", want, msg)
+		}
+	}()
+
+	router := mux.NewRouter()
+	router.Mount("/self", router.NewSubRouter("/sub"))
+}
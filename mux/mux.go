@@ -10,44 +10,254 @@ Inspired by:
 	router := mux.NewRouter()
 	router.Use(globalMiddleware1, globalMiddleware2, ...)
 	router.Handle(method, path).Use(middleware1, middleware2, ...).Then(handler)
+	router.Group(prefix, func(group *mux.Mux) {
+		group.Use(groupMiddleware1, groupMiddleware2, ...)
+		group.Handle(method, path).Then(handler)
+	})
 	http.ListenAndServe(addr, router)
 
 	See cmd/server/main.go for example.
+
+A request matching the pattern of a route but not its matchers (host, headers,
+queries, media types, version) is dispatched to the next most specific pattern,
+as if the route wasn't registered.
+
+The conflicts between the routes panic at registration. Validate reports every
+conflict, shadowed route and route without a method instead (see also cmd/routes).
+
+# Path parameters
+
+Wildcards follow the http.ServeMux syntax, with an optional constraint:
+
+	{name}       // any value
+	{name:int}   // an integer
+	{name:uint}  // an unsigned integer
+	{name:uuid}  // an UUID
+
+When a constraint fails, the InvalidParam handler (NotFound by default) is called
+instead of the route handler. Typed values are returned by ParamInt, ParamUint,
+ParamUUID and ParamTime.
+
+The handling of the unclean paths and of the trailing slashes, the case sensitivity
+and the encoded slashes are defined by the path options of the router (see Mux.Paths).
+
+# Middlewares
+
+A sub-router (or a group) inherits the middlewares of its parent at creation time.
+Middlewares added later to the parent are not applied to existing sub-routers,
+and middlewares added to a sub-router are never applied to its parent or to its siblings.
+
+A route is wrapped, from the outermost to the innermost, by the middlewares of its
+router at registration time, then by its own middlewares.
+The route matching a request (pattern, name and metadata) is available
+to the middlewares with RouteFromContext.
+
+# OpenAPI
+
+Routes take optional metadata (Summary, Description, Tags, Request, Response),
+used to generate the OpenAPI 3.1 document of the router:
+
+	router.POST("/users").Summary("Create a user").Tags("users").
+		Request(CreateUserRequest{}).Response(http.StatusCreated, User{}).
+		Then(mux.JSON(createUser))
+	router.OpenAPI("/openapi.json", mux.OpenAPIInfo{Title: "API", Version: "1.0.0"})
+
+Conversely, the requests (and, in the tests, the responses) can be validated against
+an existing OpenAPI 3 document loaded by LoadSpec (see Spec).
 */
 package mux
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Mux struct {
-	mux         *http.ServeMux
+	tables      *tables
 	prefix      string
+	host        string
+	scheme      string
+	version     *version
+	matchers    []matcher
 	middlewares []middleware
 }
 
+// tables holds the live table of a router, shared by its sub-routers.
+//
+// The live table is never modified: it is replaced by an updated copy (see Mux.update),
+// so that the requests being served never see a partial update.
+type tables struct {
+	live    atomic.Pointer[table]
+	staging atomic.Pointer[table] // the table built by Swap or Validate, if any
+	mu      sync.Mutex            // serializes the updates
+}
+
+// table holds the routes shared by a router and its sub-routers.
+type table struct {
+	mux              *http.ServeMux
+	root             *Mux
+	building         bool
+	errs             []error
+	routes           []*route
+	entries          map[string]*entry
+	names            map[string]*route
+	methods          map[string]bool
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+	invalidParam     http.Handler
+	errorHandler     ErrorHandlerFunc
+	paths            PathOptions
+	fallbacks        *sync.Map // ServeMuxes without the entries already tried (see table.without)
+	uses             [2]int    // range of the root middlewares added while building the table (see Mux.Swap)
+}
+
 type middleware = func(http.Handler) http.Handler
 
+// matcher selects the requests matching a route, in addition to its pattern.
+type matcher struct {
+	match  func(*http.Request) bool
+	status int    // status code of the response when no route matches because of the matcher
+	key    string // identifies the matcher, to detect the shadowed routes (see Validate)
+}
+
 type route struct {
 	m           *Mux
 	method      string
 	path        string
+	name        string
+	constraints map[string]string
+	matchers    []matcher
 	middlewares []middleware
 	handler     http.Handler
+	count       int
+	spec        spec
+	meta        map[string]any
+	info        *Route
+	mount       bool   // the route of a mount point (see Mux.Mount)
+	only        string // the only method served by a route registered for any method (see Mux.mount)
+}
+
+// Route describes a registered route.
+type Route struct {
+	// Method is the HTTP method of the route (empty for any method).
+	Method string
+	// Pattern is the full path of the route, including the sub-router prefix.
+	Pattern string
+	// Host is the host pattern of the route (empty for any host).
+	Host string
+	// Scheme is the URL scheme of the route (empty for any scheme).
+	Scheme string
+	// Version is the API version of the route (empty for an unversioned route).
+	Version string
+	// Name is the name of the route (empty for an unnamed route).
+	Name string
+	// Middlewares is the number of middlewares wrapping the handler.
+	Middlewares int
+	// Router is the router (or sub-router) the route was registered on.
+	Router *Mux
+	// Meta is the metadata of the route (see route.Meta). It must not be modified.
+	Meta map[string]any
+}
+
+// String returns the pattern of the route, with its method if any (e.g. "GET /status/{code}").
+func (r *Route) String() string {
+	if r.Method == "" {
+		return r.Pattern
+	}
+	return r.Method + " " + r.Pattern
+}
+
+// RouteFromContext returns the route matching the request of the context.
+// It is available to the middlewares and to the handler of the route.
+//
+// Example:
+//
+//	func rateLimit(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			if route, ok := mux.RouteFromContext(r.Context()); ok && route.Meta["tier"] == "premium" {
+//				...
+//			}
+//			next.ServeHTTP(w, r)
+//		})
+//	}
+func RouteFromContext(ctx context.Context) (*Route, bool) {
+	route, ok := ctx.Value(routeContextKey).(*Route)
+	return route, ok
 }
 
 type muxContextKey uint
 
 const (
 	paramsContextKey muxContextKey = iota
+	probeContextKey
+	mountContextKey
+	routeContextKey
+	pathContextKey
+	hostContextKey
+	dispatchContextKey
 )
 
 func NewRouter() *Mux {
-	return &Mux{
-		mux:    http.NewServeMux(),
+	m := &Mux{
+		tables: &tables{},
 		prefix: "",
 	}
+	m.tables.live.Store(newTable(m))
+	return m
+}
+
+// newTable creates a new empty table for the root router.
+func newTable(root *Mux) *table {
+	return &table{
+		mux:              http.NewServeMux(),
+		root:             root,
+		entries:          make(map[string]*entry),
+		names:            make(map[string]*route),
+		methods:          make(map[string]bool),
+		notFound:         http.NotFoundHandler(),
+		methodNotAllowed: http.HandlerFunc(methodNotAllowed),
+		errorHandler:     RenderError,
+		fallbacks:        new(sync.Map),
+	}
+}
+
+// table returns the live table of the router.
+func (m *Mux) table() *table {
+	return m.tables.live.Load()
+}
+
+// update applies fn to a copy of the live table, then replaces the live table with it.
+// The live table is not replaced if fn panics.
+// While the routes are built by Swap or Validate, fn is applied to the table being built.
+func (m *Mux) update(fn func(*table)) {
+	if t := m.tables.staging.Load(); t != nil {
+		fn(t)
+		return
+	}
+	m.tables.mu.Lock()
+	defer m.tables.mu.Unlock()
+	t := m.table().clone()
+	fn(t)
+	m.tables.live.Store(t)
+}
+
+// clone returns a copy of the table. The ServeMux and the entries, safe for
+// concurrent use, are shared.
+func (t *table) clone() *table {
+	c := *t
+	c.routes = slices.Clone(t.routes)
+	c.entries = maps.Clone(t.entries)
+	c.names = maps.Clone(t.names)
+	c.methods = maps.Clone(t.methods)
+	c.fallbacks = new(sync.Map)
+	return &c
 }
 
 // NewSubRouter creates a new sub-router with the given prefix.
@@ -62,15 +272,35 @@ func NewRouter() *Mux {
 //	apiRouter.HandleFunc("/hello", HelloHandler)
 func (m *Mux) NewSubRouter(prefix string) *Mux {
 	return &Mux{
-		mux:         m.mux,
+		tables:      m.tables,
 		prefix:      m.prefix + prefix,
-		middlewares: m.middlewares,
+		host:        m.host,
+		scheme:      m.scheme,
+		version:     m.version,
+		matchers:    slices.Clone(m.matchers),
+		middlewares: slices.Clone(m.middlewares),
 	}
 }
 
+// Group creates a new sub-router with the given prefix and calls fn with it.
+// Middlewares added to the group inside fn are scoped to the group.
+//
+// Example:
+//
+//	router.Group("/admin", func(admin *mux.Mux) {
+//		admin.Use(auth)
+//		admin.GET("/status").Then(statusHandler)
+//	})
+func (m *Mux) Group(prefix string, fn func(*Mux)) *Mux {
+	group := m.NewSubRouter(prefix)
+	fn(group)
+	return group
+}
+
 // Use adds global middlewares to the router.
+// The middlewares are only applied to the routes registered afterwards.
 func (m *Mux) Use(middlewares ...middleware) *Mux {
-	m.middlewares = append(m.middlewares, middlewares...)
+	m.middlewares = slices.Concat(m.middlewares, middlewares)
 	return m
 }
 
@@ -104,6 +334,27 @@ func (m *Mux) DELETE(p string) *route {
 	return m.Handle("DELETE", p)
 }
 
+// OPTIONS sets a route with the OPTIONS HTTP method.
+func (m *Mux) OPTIONS(p string) *route {
+	return m.Handle("OPTIONS", p)
+}
+
+// Name sets the name of a route, used to build its URL with Mux.URL.
+func (r *route) Name(name string) *route {
+	r.name = name
+	return r
+}
+
+// Meta attaches metadata to a route (e.g. auth scopes, rate-limit tiers),
+// available to the middlewares with RouteFromContext.
+func (r *route) Meta(key string, value any) *route {
+	if r.meta == nil {
+		r.meta = make(map[string]any)
+	}
+	r.meta[key] = value
+	return r
+}
+
 // Use adds middlewares to a specific route.
 func (r *route) Use(middlewares ...middleware) *route {
 	r.middlewares = append(r.middlewares, middlewares...)
@@ -111,30 +362,430 @@ func (r *route) Use(middlewares ...middleware) *route {
 }
 
 // Then sets the final handler for a route using an http.Handler.
-func (r *route) Then(h http.Handler) {
+//
+// Then panics if the route is invalid or conflicts with another route,
+// except when the routes are built by Swap: the error is returned instead.
+//
+// Routes can be registered while the router serves requests: the route table
+// is replaced by an updated copy.
+func (r *route) Then(h http.Handler) (err error) {
+	r.m.update(func(t *table) {
+		if t.building {
+			defer func() {
+				if v := recover(); v != nil {
+					err = &routeError{route: r.method + " " + r.path, v: v}
+					t.errs = append(t.errs, err)
+				}
+			}()
+		}
+		r.register(t, h)
+	})
+	return err
+}
+
+// register registers the route in the table.
+func (r *route) register(t *table, h http.Handler) {
 	if len(r.path) < 1 || r.path[0] != '/' {
 		panic("path must begin with '/'")
 	}
 	if h == nil {
 		panic("handler must not be nil")
 	}
+	if _, found := t.names[r.name]; found && r.name != "" {
+		panic(fmt.Sprintf("route name already registered: '%s'", r.name))
+	}
 
-	middlewares := append(r.m.middlewares, r.middlewares...)
-	for i := range middlewares {
-		h = middlewares[len(middlewares)-1-i](h)
+	r.path, r.constraints = parseConstraints(r.path)
+
+	middlewares := slices.Concat(r.m.middlewares, r.middlewares)
+	h = chain(h, middlewares)
+	if r.constraints != nil {
+		h = checkConstraints(h, r.constraints, func() http.Handler {
+			t := r.m.table()
+			if t.invalidParam != nil {
+				return t.fallback(t.invalidParam)
+			}
+			return t.fallback(t.notFound)
+		})
 	}
 	r.handler = h
+	r.count = len(middlewares)
+	r.matchers = slices.Concat(r.m.matchers, r.matchers)
 
-	pattern := fmt.Sprintf("%s %s", r.method, r.path)
-	r.m.mux.Handle(pattern, h)
+	pattern := r.path
+	if t.paths.CaseInsensitive {
+		pattern = foldPattern(pattern)
+	}
+	if host := r.m.host; host != "" && literalHost(host) {
+		pattern = strings.ToLower(host) + pattern
+	}
+	if r.method != "" {
+		pattern = fmt.Sprintf("%s %s", r.method, pattern)
+	}
+	r.info = r.describe()
+	if e, found := t.entries[pattern]; found {
+		e.add(pattern, r)
+	} else {
+		e = &entry{tables: r.m.tables, pattern: pattern, wildcards: wildcards(r.path)}
+		e.add(pattern, r)
+		t.mux.Handle(pattern, e)
+		t.entries[pattern] = e
+	}
+	t.routes = append(t.routes, r)
+	if method := cmp.Or(r.method, r.only); method != "" {
+		t.methods[method] = true
+	}
+	if r.name != "" {
+		t.names[r.name] = r
+	}
 }
 
 // ThenFunc sets the final handler for a route using an http.HandlerFunc.
-func (r *route) ThenFunc(h http.HandlerFunc) {
-	r.Then(http.HandlerFunc(h))
+func (r *route) ThenFunc(h http.HandlerFunc) error {
+	return r.Then(http.HandlerFunc(h))
+}
+
+// Prefix returns the prefix of the router.
+func (m *Mux) Prefix() string {
+	return m.prefix
+}
+
+// Routes returns all the routes registered on the underlying ServeMux
+// (shared by the router and its sub-routers), in registration order.
+func (m *Mux) Routes() []Route {
+	t := m.table()
+	routes := make([]Route, 0, len(t.routes))
+	for _, r := range t.routes {
+		routes = append(routes, *r.info)
+	}
+	return routes
+}
+
+// describe returns the description of the registered route.
+func (r *route) describe() *Route {
+	version := ""
+	if r.m.version != nil {
+		version = r.m.version.name()
+	}
+	return &Route{
+		Method:      r.method,
+		Pattern:     r.path,
+		Host:        r.m.host,
+		Scheme:      r.m.scheme,
+		Version:     version,
+		Name:        r.name,
+		Middlewares: r.count,
+		Router:      r.m,
+		Meta:        r.meta,
+	}
+}
+
+// NotFound sets the handler called when no route matches the request.
+// The handler is wrapped by the global middlewares of the root router.
+func (m *Mux) NotFound(h http.Handler) *Mux {
+	if h == nil {
+		panic("handler must not be nil")
+	}
+	m.update(func(t *table) { t.notFound = h })
+	return m
+}
+
+// MethodNotAllowed sets the handler called when a route matches the request path,
+// but not the request method. The Allow header is set before the handler is called.
+// The handler is wrapped by the global middlewares of the root router.
+func (m *Mux) MethodNotAllowed(h http.Handler) *Mux {
+	if h == nil {
+		panic("handler must not be nil")
+	}
+	m.update(func(t *table) { t.methodNotAllowed = h })
+	return m
+}
+
+// InvalidParam sets the handler called when a path parameter doesn't satisfy
+// the constraint of the matching route (e.g. {code:int}).
+// By default, the NotFound handler is called.
+// The handler is wrapped by the global middlewares of the root router.
+func (m *Mux) InvalidParam(h http.Handler) *Mux {
+	if h == nil {
+		panic("handler must not be nil")
+	}
+	m.update(func(t *table) { t.invalidParam = h })
+	return m
 }
 
 // ServeHTTP implements the http.Handler interface for the router.
+//
+// When no route matches the request, the router answers OPTIONS requests
+// with the methods allowed for the path, and calls the MethodNotAllowed handler
+// (or the NotFound handler if no method is allowed) for other requests.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	m.mux.ServeHTTP(w, r)
+	m.table().ServeHTTP(w, r)
+}
+
+func (t *table) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = foldHost(r)
+	if t.paths != (PathOptions{}) {
+		var redirected bool
+		if r, redirected = t.preparePath(w, r); redirected {
+			return
+		}
+	}
+	// The redirects of the ServeMux only apply with the default path policy.
+	if h, pattern := t.mux.Handler(r); isEntry(h) || pattern != "" && t.paths.Policy == PathDefault {
+		t.mux.ServeHTTP(w, r)
+		return
+	}
+	t.serveUnmatched(w, r)
+}
+
+// serveUnmatched serves a request matching no route, with the methods allowed
+// for the request path.
+func (t *table) serveUnmatched(w http.ResponseWriter, r *http.Request) {
+	var h http.Handler
+	allowed := t.allowedMethods(r)
+	switch {
+	case len(allowed) == 0:
+		h = t.notFound
+	case r.Method == http.MethodOptions:
+		h = http.HandlerFunc(options)
+	default:
+		h = t.methodNotAllowed
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	t.fallback(h).ServeHTTP(w, r)
+}
+
+// allowedMethods returns the sorted methods matching the request path.
+//
+// The request is dispatched once per registered method, in probe mode:
+// the matching entry records the match instead of serving the request.
+func (t *table) allowedMethods(r *http.Request) []string {
+	var allowed []string
+	for method := range t.methods {
+		p := &probe{}
+		req := r.WithContext(context.WithValue(r.Context(), probeContextKey, p))
+		req.Method = method
+		t.mux.ServeHTTP(discardResponseWriter{}, req)
+		if p.matched {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	if slices.Contains(allowed, http.MethodGet) {
+		// GET routes also match HEAD requests
+		allowed = append(allowed, http.MethodHead)
+	}
+	allowed = append(allowed, http.MethodOptions)
+	slices.Sort(allowed)
+	return slices.Compact(allowed)
+}
+
+// fallback wraps the handler with the global middlewares of the root router.
+func (t *table) fallback(h http.Handler) http.Handler {
+	return chain(h, t.root.middlewares)
+}
+
+// entry dispatches the requests matching a ServeMux pattern
+// to the first route whose matchers all pass.
+//
+// When no route matches, the request is dispatched to the entry of the next most
+// specific pattern matching it, as if the pattern wasn't registered, so that the
+// routes with matchers don't change how the other routes behave.
+// When no entry has a matching route, the response is 415 Unsupported Media Type
+// if a route only failed on its Consumes matcher, 406 Not Acceptable if a route
+// only failed on its Produces matcher, and the response of the router for an
+// unmatched request otherwise (see table.serveUnmatched).
+type entry struct {
+	tables    *tables
+	pattern   string
+	routes    atomic.Pointer[[]*route] // replaced, never modified, when a route is added
+	wildcards []string                 // wildcard names by path segment (see wildcards)
+}
+
+// list returns the routes of the entry.
+func (e *entry) list() []*route {
+	if routes := e.routes.Load(); routes != nil {
+		return *routes
+	}
+	return nil
+}
+
+// add adds a route to the entry.
+// The route without matchers, if any, is always the last one.
+func (e *entry) add(pattern string, r *route) {
+	routes := e.list()
+	for _, other := range routes {
+		if sameMatchers(r, other) {
+			panic(fmt.Sprintf("route already registered: '%s'", pattern))
+		}
+	}
+	i := len(routes)
+	if i > 0 && len(routes[i-1].matchers) == 0 {
+		i--
+	}
+	routes = slices.Insert(slices.Clone(routes), i, r)
+	e.routes.Store(&routes)
+}
+
+// sameMatchers reports whether the routes have the same matchers, compared by key.
+// The matchers without key are never considered as equal.
+func sameMatchers(a, b *route) bool {
+	keys := func(r *route) []string {
+		keys := make([]string, len(r.matchers))
+		for i, m := range r.matchers {
+			if m.key == "" {
+				return nil
+			}
+			keys[i] = m.key
+		}
+		slices.Sort(keys)
+		return keys
+	}
+	ka, kb := keys(a), keys(b)
+	return ka != nil && kb != nil && slices.Equal(ka, kb)
+}
+
+// match returns the first route matching the request,
+// or the status code of the response if no route matches.
+func (e *entry) match(r *http.Request) (*route, int) {
+	status := http.StatusNotFound
+	for _, rt := range e.list() {
+		code := rt.match(r)
+		if code == 0 {
+			return rt, 0
+		}
+		status = precedence(status, code)
+	}
+	return nil, status
+}
+
+// precedence returns the status code of the response when no route matches,
+// given the status codes of two failing routes: 415 takes precedence over 406,
+// then 405 and 404.
+func precedence(status, code int) int {
+	switch {
+	case code == http.StatusUnsupportedMediaType,
+		code == http.StatusNotAcceptable && status != http.StatusUnsupportedMediaType,
+		code == http.StatusMethodNotAllowed && status == http.StatusNotFound:
+		return code
+	}
+	return status
+}
+
+func (e *entry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := r // dispatched again if no route matches
+	r = e.restore(r)
+	p, probing := r.Context().Value(probeContextKey).(*probe)
+	rt, status := e.match(r)
+	switch {
+	case rt == nil:
+		e.next(w, req, status)
+	case probing:
+		p.matched = true
+	default:
+		ctx := context.WithValue(r.Context(), routeContextKey, rt.info)
+		if _, ok := ctx.Value(dispatchContextKey).(*dispatch); ok {
+			ctx = context.WithValue(ctx, dispatchContextKey, nil)
+		}
+		rt.handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// dispatch records the entries already tried for a request matching none of their routes.
+type dispatch struct {
+	tables   *tables
+	patterns []string
+	status   int // status code of the response if no route matches (see precedence)
+}
+
+// next dispatches a request matching none of the routes of the entry to the entry
+// of the next most specific pattern matching it, or serves it as unmatched.
+func (e *entry) next(w http.ResponseWriter, r *http.Request, status int) {
+	t := e.tables.live.Load()
+	d, ok := r.Context().Value(dispatchContextKey).(*dispatch)
+	if !ok || d.tables != e.tables {
+		// first entry tried, or entry of a router mounted on a route
+		d = &dispatch{tables: e.tables, status: http.StatusNotFound}
+	}
+	d = &dispatch{
+		tables:   e.tables,
+		patterns: append(slices.Clip(d.patterns), e.pattern),
+		status:   precedence(d.status, status),
+	}
+	mux := t.without(d.patterns)
+	if h, _ := mux.Handler(r); isEntry(h) {
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), dispatchContextKey, d)))
+		return
+	}
+	if _, probing := r.Context().Value(probeContextKey).(*probe); probing {
+		return
+	}
+	switch d.status {
+	case http.StatusUnsupportedMediaType, http.StatusNotAcceptable:
+		t.fallback(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(d.status), d.status)
+		})).ServeHTTP(w, r)
+	default:
+		t.serveUnmatched(w, r)
+	}
+}
+
+// without returns a ServeMux with the entries of the table, except those of the patterns.
+// The ServeMuxes are built once per table.
+func (t *table) without(patterns []string) *http.ServeMux {
+	key := strings.Join(slices.Sorted(slices.Values(patterns)), "\n")
+	if mux, ok := t.fallbacks.Load(key); ok {
+		return mux.(*http.ServeMux)
+	}
+	mux := http.NewServeMux()
+	for pattern, e := range t.entries {
+		if !slices.Contains(patterns, pattern) {
+			mux.Handle(pattern, e)
+		}
+	}
+	v, _ := t.fallbacks.LoadOrStore(key, mux)
+	return v.(*http.ServeMux)
+}
+
+// match returns 0 if all the matchers of the route pass,
+// or the status code of the first failing matcher.
+func (r *route) match(req *http.Request) int {
+	for _, m := range r.matchers {
+		if !m.match(req) {
+			return m.status
+		}
+	}
+	return 0
+}
+
+// chain wraps the handler with the middlewares, the first middleware being the outermost.
+func chain(h http.Handler, middlewares []middleware) http.Handler {
+	for i := range middlewares {
+		h = middlewares[len(middlewares)-1-i](h)
+	}
+	return h
+}
+
+// probe records whether a request dispatched in probe mode matches a route.
+type probe struct {
+	matched bool
+}
+
+// discardResponseWriter is the response writer of the requests dispatched in probe mode.
+type discardResponseWriter struct{}
+
+func (discardResponseWriter) Header() http.Header         { return http.Header{} }
+func (discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardResponseWriter) WriteHeader(int)             {}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+func options(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -1,9 +1,13 @@
 package mux_test
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/carlito767/go-stack/mux"
@@ -18,6 +22,15 @@ func m(msg string) func(http.Handler) http.Handler {
 	}
 }
 
+func trace(msg string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", msg)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func TestPanic(t *testing.T) {
 	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
 
@@ -97,14 +110,15 @@ func TestRouter(t *testing.T) {
 		t.Fatalf("response body expected: %#v, got: %#v", expected, res.Body.String())
 	}
 
-	// test the router with an invalid route
+	// test the router with an invalid route (global middlewares still apply)
 	paths := []string{"/invalid/path", "/1/2/3"}
 	for _, path := range paths {
 		req = httptest.NewRequest("GET", path, nil)
 		res = httptest.NewRecorder()
 		router.ServeHTTP(res, req)
-		if res.Code != http.StatusNotFound {
-			t.Errorf("status code expected: %d, got: %d", http.StatusNotFound, res.Code)
+		expected := "12404 page not found\n"
+		if res.Body.String() != expected {
+			t.Errorf("response body expected: %#v, got: %#v", expected, res.Body.String())
 		}
 	}
 }
@@ -251,3 +265,213 @@ func TestSubRouterPrefixChaining(t *testing.T) {
 		t.Errorf("GET /api/v0/admin/status = %q; want %q", got, "admin status")
 	}
 }
+
+func TestRoutesListing(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Use(m("1"))
+	api := router.NewSubRouter("/api")
+	api.Use(m("2"))
+
+	router.GET("/home").Then(h)
+	api.POST("/items/{id}").Use(m("3"), m("4")).Then(h)
+	router.Handle("", "/files/").Then(h)
+
+	want := []mux.Route{
+		{Method: "GET", Pattern: "/home", Middlewares: 1, Router: router},
+		{Method: "POST", Pattern: "/api/items/{id}", Middlewares: 4, Router: api},
+		{Method: "", Pattern: "/files/", Middlewares: 1, Router: router},
+	}
+
+	for _, m := range []*mux.Mux{router, api} {
+		got := m.Routes()
+		if len(got) != len(want) {
+			t.Fatalf("routes expected: %d, got: %d", len(want), len(got))
+		}
+		for i := range want {
+			if !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("route %d expected: %+v, got: %+v", i, want[i], got[i])
+			}
+		}
+	}
+
+	if got := api.Prefix(); got != "/api" {
+		t.Errorf("prefix expected: %q, got: %q", "/api", got)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Use(m("1"))
+	router.Use(m("2"))
+	router.Use(m("3")) // the backing array has spare capacity
+
+	sub1 := router.NewSubRouter("/sub1")
+	sub2 := router.NewSubRouter("/sub2")
+	sub1.Use(m("a"))
+	sub2.Use(m("b"))
+	sub1.GET("/").Then(h)
+	sub2.GET("/").Then(h)
+
+	router.Group("/group1", func(group *mux.Mux) {
+		group.Use(m("c"))
+		group.GET("/").Use(m("4")).Then(h)
+		group.Group("/nested", func(nested *mux.Mux) {
+			nested.Use(m("d"))
+			nested.GET("/").Then(h)
+		})
+		group.GET("/after").Then(h)
+	})
+	router.Group("/group2", func(group *mux.Mux) {
+		group.Use(m("e"))
+		group.GET("/").Use(m("5")).Then(h)
+	})
+
+	router.GET("/").Use(m("6")).Then(h)
+	router.GET("/other").Use(m("7")).Then(h)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/sub1/", "123a"},
+		{"/sub2/", "123b"},
+		{"/group1/", "123c4"},
+		{"/group1/nested/", "123cd"},
+		{"/group1/after", "123c"},
+		{"/group2/", "123e5"},
+		{"/", "1236"},
+		{"/other", "1237"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if got := res.Body.String(); got != tt.want {
+			t.Errorf("GET %q = %q; want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Use(trace("1"))
+	router.GET("/items").Then(h)
+	router.POST("/items").Then(h)
+	router.DELETE("/items/{id}").Then(h)
+	router.OPTIONS("/custom").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("custom"))
+	})
+	router.Use(trace("2"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		allow  string
+		trace  string
+	}{
+		{"not found", "GET", "/unknown", http.StatusNotFound, "", "1,2"},
+		{"method not allowed", "PUT", "/items", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST", "1,2"},
+		{"wildcard", "GET", "/items/1", http.StatusMethodNotAllowed, "DELETE, OPTIONS", "1,2"},
+		{"options", "OPTIONS", "/items", http.StatusNoContent, "GET, HEAD, OPTIONS, POST", "1,2"},
+		{"explicit options", "OPTIONS", "/custom", http.StatusOK, "", "1"},
+		{"options not found", "OPTIONS", "/unknown", http.StatusNotFound, "", "1,2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow header expected: %q, got: %q", tt.allow, got)
+			}
+			if got := strings.Join(res.Header().Values("X-Trace"), ","); got != tt.trace {
+				t.Errorf("trace expected: %q, got: %q", tt.trace, got)
+			}
+		})
+	}
+}
+
+func TestCustomNotFoundAndMethodNotAllowed(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/items").ThenFunc(func(http.ResponseWriter, *http.Request) {})
+	router.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	router.MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(w.Header().Get("Allow")))
+	}))
+
+	req := httptest.NewRequest("GET", "/unknown", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusTeapot {
+		t.Errorf("status code expected: %d, got: %d", http.StatusTeapot, res.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/items", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusConflict {
+		t.Errorf("status code expected: %d, got: %d", http.StatusConflict, res.Code)
+	}
+	if want := "GET, HEAD, OPTIONS"; res.Body.String() != want {
+		t.Errorf("response body expected: %q, got: %q", want, res.Body.String())
+	}
+}
+
+func TestRouteFromContext(t *testing.T) {
+	routeInfo := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := mux.RouteFromContext(r.Context())
+			if !ok {
+				w.Header().Set("X-Route", "none")
+			} else {
+				w.Header().Set("X-Route", fmt.Sprintf("%s|%s|%v", route, route.Name, route.Meta["tier"]))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Use(routeInfo)
+	router.GET("/status/{code:int}").Name("status").Meta("tier", "premium").Then(h)
+	api := router.NewSubRouter("/api")
+	api.Handle("", "/items/{id}").Then(h)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/status/200", "GET /status/{code}|status|premium"},
+		{"/api/items/42", "/api/items/{id}||<nil>"},
+		{"/unknown", "none"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Route"); got != tt.want {
+			t.Errorf("GET %s: route expected: %q, got: %q", tt.path, tt.want, got)
+		}
+	}
+
+	if _, ok := mux.RouteFromContext(context.Background()); ok {
+		t.Error("route expected: none")
+	}
+}
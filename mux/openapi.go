@@ -0,0 +1,426 @@
+package mux
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIInfo is the metadata of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// spec holds the OpenAPI metadata of a route.
+type spec struct {
+	summary     string
+	description string
+	tags        []string
+	request     reflect.Type
+	responses   map[int]reflect.Type
+	hidden      bool
+}
+
+// Summary sets the summary of the route operation (OpenAPI).
+func (r *route) Summary(summary string) *route {
+	r.spec.summary = summary
+	return r
+}
+
+// Description sets the description of the route operation (OpenAPI).
+func (r *route) Description(description string) *route {
+	r.spec.description = description
+	return r
+}
+
+// Tags adds tags to the route operation (OpenAPI).
+func (r *route) Tags(tags ...string) *route {
+	r.spec.tags = append(r.spec.tags, tags...)
+	return r
+}
+
+// Request sets the type of the JSON request body of the route operation (OpenAPI),
+// from a value of the type (e.g. CreateUserRequest{}).
+func (r *route) Request(v any) *route {
+	r.spec.request = reflect.TypeOf(v)
+	return r
+}
+
+// Response adds a response to the route operation (OpenAPI), with the type
+// of the JSON response body, from a value of the type (nil for no body).
+func (r *route) Response(status int, v any) *route {
+	if r.spec.responses == nil {
+		r.spec.responses = make(map[int]reflect.Type)
+	}
+	r.spec.responses[status] = reflect.TypeOf(v)
+	return r
+}
+
+// OpenAPI registers a GET route serving the OpenAPI 3.1 document of the router
+// at the given path (see OpenAPIDocument).
+func (m *Mux) OpenAPI(path string, info OpenAPIInfo) error {
+	r := m.GET(path)
+	r.spec.hidden = true
+	return r.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := m.OpenAPIDocument(info)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+}
+
+// OpenAPIDocument generates the OpenAPI 3.1 document of the routes with a method.
+//
+// The path parameters are the wildcards of the routes, and the schemas are
+// generated from the request and response types. The document is the same
+// for the same routes (paths, methods and properties are sorted).
+//
+// The routes with the same method and path (e.g. with different hosts or versions)
+// are merged into one operation, described by the first route: their tags, parameters
+// and responses are added, and their different schemas are alternatives (oneOf).
+func (m *Mux) OpenAPIDocument(info OpenAPIInfo) ([]byte, error) {
+	g := &schemaGenerator{names: make(map[reflect.Type]string), schemas: make(map[string]*schema)}
+	doc := document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   make(map[string]map[string]*operation),
+	}
+	for _, r := range m.table().routes {
+		if r.method == "" || r.spec.hidden {
+			continue
+		}
+		path, params := openAPIPath(r.path, r.constraints)
+		op := &operation{
+			OperationID: r.name,
+			Summary:     r.spec.summary,
+			Description: r.spec.description,
+			Tags:        r.spec.tags,
+			Parameters:  params,
+			Responses:   make(map[string]*response),
+		}
+		if r.spec.request != nil {
+			op.RequestBody = &requestBody{
+				Required: true,
+				Content:  map[string]*mediaType{"application/json": {Schema: g.schema(r.spec.request)}},
+			}
+		}
+		for _, status := range slices.Sorted(maps.Keys(r.spec.responses)) {
+			t := r.spec.responses[status]
+			resp := &response{Description: http.StatusText(status)}
+			if t != nil {
+				resp.Content = map[string]*mediaType{"application/json": {Schema: g.schema(t)}}
+			}
+			op.Responses[strconv.Itoa(status)] = resp
+		}
+		if len(op.Responses) == 0 {
+			op.Responses["200"] = &response{Description: http.StatusText(http.StatusOK)}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operation)
+		}
+		method := strings.ToLower(r.method)
+		if other, found := doc.Paths[path][method]; found {
+			other.merge(op)
+		} else {
+			doc.Paths[path][method] = op
+		}
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &components{Schemas: g.schemas}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components *components                      `json:"components,omitempty"`
+}
+
+type components struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*schema          `json:"oneOf,omitempty"`
+}
+
+// merge merges the operation of another route with the same method and path.
+func (op *operation) merge(other *operation) {
+	op.OperationID = cmp.Or(op.OperationID, other.OperationID)
+	op.Summary = cmp.Or(op.Summary, other.Summary)
+	op.Description = cmp.Or(op.Description, other.Description)
+	for _, tag := range other.Tags {
+		if !slices.Contains(op.Tags, tag) {
+			op.Tags = append(slices.Clip(op.Tags), tag)
+		}
+	}
+	for _, p := range other.Parameters {
+		if !slices.ContainsFunc(op.Parameters, func(q *parameter) bool { return q.Name == p.Name && q.In == p.In }) {
+			op.Parameters = append(slices.Clip(op.Parameters), p)
+		}
+	}
+	switch {
+	case op.RequestBody != nil && other.RequestBody != nil:
+		op.RequestBody.Content = mergeContent(op.RequestBody.Content, other.RequestBody.Content)
+	case op.RequestBody != nil || other.RequestBody != nil:
+		// the body is optional for one of the routes
+		op.RequestBody = &requestBody{Content: cmp.Or(op.RequestBody, other.RequestBody).Content}
+	}
+	for status, resp := range other.Responses {
+		if r, found := op.Responses[status]; found {
+			r.Content = mergeContent(r.Content, resp.Content)
+		} else {
+			op.Responses[status] = resp
+		}
+	}
+}
+
+// mergeContent merges the media types of two contents.
+func mergeContent(content, other map[string]*mediaType) map[string]*mediaType {
+	merged := maps.Clone(content)
+	for name, mt := range other {
+		if merged == nil {
+			merged = make(map[string]*mediaType)
+		}
+		if existing, found := merged[name]; found {
+			merged[name] = &mediaType{Schema: oneOf(existing.Schema, mt.Schema)}
+		} else {
+			merged[name] = mt
+		}
+	}
+	return merged
+}
+
+// oneOf returns the schema matching either schema.
+func oneOf(s, other *schema) *schema {
+	schemas := []*schema{s}
+	if s.OneOf != nil && reflect.DeepEqual(*s, schema{OneOf: s.OneOf}) {
+		schemas = s.OneOf
+	}
+	if slices.ContainsFunc(schemas, func(s *schema) bool { return reflect.DeepEqual(s, other) }) {
+		return s
+	}
+	return &schema{OneOf: append(slices.Clip(schemas), other)}
+}
+
+// openAPIPath converts the route path to an OpenAPI path template,
+// and returns its path parameters.
+func openAPIPath(path string, constraints map[string]string) (string, []*parameter) {
+	var b strings.Builder
+	var params []*parameter
+	for {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(path[:i])
+		wildcard := path[i+1 : i+j]
+		path = path[i+j+1:]
+		if wildcard == "$" {
+			continue
+		}
+		name := strings.TrimSuffix(wildcard, "...")
+		b.WriteString("{" + name + "}")
+		params = append(params, &parameter{Name: name, In: "path", Required: true, Schema: constraintSchema(constraints[name])})
+	}
+	b.WriteString(path)
+	return b.String(), params
+}
+
+// constraintSchema returns the schema of a path parameter with the given constraint.
+func constraintSchema(constraint string) *schema {
+	switch constraint {
+	case "int":
+		return &schema{Type: "integer"}
+	case "uint":
+		return &schema{Type: "integer", Minimum: new(int)}
+	case "uuid":
+		return &schema{Type: "string", Format: "uuid"}
+	}
+	return &schema{Type: "string"}
+}
+
+// schemaGenerator generates the schemas of Go types.
+// The named struct types are generated once, as components.
+type schemaGenerator struct {
+	names   map[reflect.Type]string
+	schemas map[string]*schema
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	componentName     = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &schema{Type: "integer", Minimum: new(int)}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name, found := g.names[t]
+		if !found {
+			name = g.componentName(t)
+			g.names[t] = name
+			g.schemas[name] = &schema{} // placeholder for the recursive types
+			*g.schemas[name] = *g.object(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	return &schema{}
+}
+
+// componentName returns a unique component name for the named type.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	name := componentName.ReplaceAllString(t.Name(), "_")
+	if _, found := g.schemas[name]; found {
+		name = componentName.ReplaceAllString(t.PkgPath()+"."+t.Name(), "_")
+	}
+	base := name
+	for i := 2; ; i++ {
+		if _, found := g.schemas[name]; !found {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// object returns the object schema of the struct type, following the encoding/json rules.
+func (g *schemaGenerator) object(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	g.fields(s, t)
+	slices.Sort(s.Required)
+	return s
+}
+
+func (g *schemaGenerator) fields(s *schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		options := strings.Split(opts, ",")
+		ft := field.Type
+		if field.Anonymous && name == "" {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if slices.Contains(options, "string") {
+			s.Properties[name] = &schema{Type: "string"}
+		} else {
+			s.Properties[name] = g.schema(ft)
+		}
+		optional := slices.Contains(options, "omitempty") || slices.Contains(options, "omitzero")
+		if !optional && ft.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package mux_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type user struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Tags      []string  `json:"tags"`
+	Address   *address  `json:"address"`
+	Manager   *user     `json:"manager,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	secret    string
+}
+
+type createUser struct {
+	Name    string  `json:"name"`
+	Address address `json:"address"`
+	Admin   bool    `json:"-"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.GET("/users").Summary("List users").Tags("users").Response(http.StatusOK, []user{}).Then(h)
+	router.POST("/users").Name("createUser").Summary("Create a user").Tags("users").
+		Request(createUser{}).Response(http.StatusCreated, user{}).Response(http.StatusBadRequest, nil).Then(h)
+	router.GET("/users/{id:uint}").Description("Returns a user.").Response(http.StatusOK, &user{}).Then(h)
+	router.GET("/files/{path...}").Then(h)
+	router.GET("/{$}").Then(h)
+	router.Handle("", "/any").Then(h)
+	if err := router.OpenAPI("/openapi.json", mux.OpenAPIInfo{Title: "Test", Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "openapi": "3.1.0",
+  "info": {"title": "Test", "version": "1.0.0"},
+  "paths": {
+    "/": {"get": {"responses": {"200": {"description": "OK"}}}},
+    "/files/{path}": {
+      "get": {
+        "parameters": [{"name": "path", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "OK"}}
+      }
+    },
+    "/users": {
+      "get": {
+        "summary": "List users",
+        "tags": ["users"],
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/user"}}}}
+          }
+        }
+      },
+      "post": {
+        "operationId": "createUser",
+        "summary": "Create a user",
+        "tags": ["users"],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/createUser"}}}
+        },
+        "responses": {
+          "201": {"description": "Created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/user"}}}},
+          "400": {"description": "Bad Request"}
+        }
+      }
+    },
+    "/users/{id}": {
+      "get": {
+        "description": "Returns a user.",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 0}}],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/user"}}}}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "address": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]},
+      "createUser": {
+        "type": "object",
+        "properties": {"address": {"$ref": "#/components/schemas/address"}, "name": {"type": "string"}},
+        "required": ["address", "name"]
+      },
+      "user": {
+        "type": "object",
+        "properties": {
+          "address": {"$ref": "#/components/schemas/address"},
+          "created_at": {"type": "string", "format": "date-time"},
+          "email": {"type": "string"},
+          "id": {"type": "integer", "format": "int64"},
+          "manager": {"$ref": "#/components/schemas/user"},
+          "name": {"type": "string"},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        },
+        "required": ["created_at", "id", "name", "tags"]
+      }
+    }
+  }
+}`
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	res := rec.Result()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status code: got %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := res.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("content type: got '%s', want 'application/json'", got)
+	}
+	var got, expected any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("document:\n%s", body)
+	}
+
+	for range 10 {
+		doc, err := router.OpenAPIDocument(mux.OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(doc) != string(body) {
+			t.Fatalf("document is not deterministic:\n%s", doc)
+		}
+	}
+}
+
+// Cookie has the same name as http.Cookie.
+type Cookie struct {
+	Value string `json:"value"`
+}
+
+func TestOpenAPIMerge(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Host("a.example.com").GET("/cookies").Summary("List cookies").Tags("a").
+		Response(http.StatusOK, Cookie{}).Response(http.StatusCreated, http.Cookie{}).Then(h)
+	router.Host("b.example.com").GET("/cookies").Summary("List other cookies").Tags("a", "b").
+		Response(http.StatusOK, []Cookie{}).Response(http.StatusNotFound, nil).Then(h)
+
+	b, err := router.OpenAPIDocument(mux.OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary   string
+			Tags      []string
+			Responses map[string]json.RawMessage
+		}
+		Components struct {
+			Schemas map[string]json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	op := doc.Paths["/cookies"]["get"]
+	if op.Summary != "List cookies" || !reflect.DeepEqual(op.Tags, []string{"a", "b"}) || len(op.Responses) != 3 {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+	want := `{"description":"OK","content":{"application/json":{"schema":{"oneOf":[{"$ref":"#/components/schemas/Cookie"},{"type":"array","items":{"$ref":"#/components/schemas/Cookie"}}]}}}}`
+	if got := string(op.Responses["200"]); compactJSON(t, got) != want {
+		t.Errorf("200 response: got %s, want %s", got, want)
+	}
+	// the status codes are walked in order: Cookie is the type of the 200 response
+	if got := compactJSON(t, string(doc.Components.Schemas["Cookie"])); !strings.Contains(got, `"value"`) {
+		t.Errorf("Cookie schema: got %s", got)
+	}
+
+	for range 10 {
+		doc, err := router.OpenAPIDocument(mux.OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(doc) != string(b) {
+			t.Fatalf("document is not deterministic:\n%s", doc)
+		}
+	}
+}
+
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
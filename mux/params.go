@@ -0,0 +1,148 @@
+package mux
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UUID is a universally unique identifier (RFC 9562).
+type UUID [16]byte
+
+// String returns the canonical form of the UUID (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx).
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// ParseUUID parses a UUID in its canonical form (case insensitive).
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID: '%s'", s)
+	}
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, fmt.Errorf("invalid UUID: '%s'", s)
+	}
+	return u, nil
+}
+
+// parsers maps the supported constraint names to their parser.
+var parsers = map[string]func(string) (any, error){
+	"int": func(s string) (any, error) {
+		return strconv.Atoi(s)
+	},
+	"uint": func(s string) (any, error) {
+		v, err := strconv.ParseUint(s, 10, strconv.IntSize)
+		return uint(v), err
+	},
+	"uuid": func(s string) (any, error) {
+		return ParseUUID(s)
+	},
+}
+
+// parseConstraints removes the constraints from the wildcards of the path
+// ({name:constraint} becomes {name}), and returns them by wildcard name.
+func parseConstraints(path string) (string, map[string]string) {
+	var b strings.Builder
+	var found map[string]string
+	for {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			break
+		}
+		wildcard := path[i+1 : i+j]
+		if name, constraint, ok := strings.Cut(wildcard, ":"); ok {
+			if _, ok := parsers[constraint]; !ok {
+				panic(fmt.Sprintf("unknown constraint: '%s'", constraint))
+			}
+			if found == nil {
+				found = make(map[string]string)
+			}
+			found[name] = constraint
+			wildcard = name
+		}
+		b.WriteString(path[:i+1])
+		b.WriteString(wildcard)
+		b.WriteByte('}')
+		path = path[i+j+1:]
+	}
+	b.WriteString(path)
+	return b.String(), found
+}
+
+// checkConstraints wraps the handler of a route to parse its constrained parameters.
+// The parsed values are stored in the request context, and the fallback handler
+// is called if a parameter doesn't satisfy its constraint.
+func checkConstraints(h http.Handler, constraints map[string]string, fallback func() http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]any, len(constraints))
+		for name, constraint := range constraints {
+			v, err := parsers[constraint](r.PathValue(name))
+			if err != nil {
+				fallback().ServeHTTP(w, r)
+				return
+			}
+			params[name] = v
+		}
+		ctx := context.WithValue(r.Context(), paramsContextKey, params)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// param returns the value of the path parameter, as parsed by its constraint if any.
+func param[T any](r *http.Request, name string, parse func(string) (T, error)) (T, error) {
+	if params, ok := r.Context().Value(paramsContextKey).(map[string]any); ok {
+		if v, ok := params[name].(T); ok {
+			return v, nil
+		}
+	}
+	v, err := parse(r.PathValue(name))
+	if err != nil {
+		return v, fmt.Errorf("invalid parameter '%s': %w", name, err)
+	}
+	return v, nil
+}
+
+// ParamInt returns the value of the path parameter as an int.
+func ParamInt(r *http.Request, name string) (int, error) {
+	return param(r, name, strconv.Atoi)
+}
+
+// ParamUint returns the value of the path parameter as an uint.
+func ParamUint(r *http.Request, name string) (uint, error) {
+	return param(r, name, func(s string) (uint, error) {
+		v, err := strconv.ParseUint(s, 10, strconv.IntSize)
+		return uint(v), err
+	})
+}
+
+// ParamUUID returns the value of the path parameter as an UUID.
+func ParamUUID(r *http.Request, name string) (UUID, error) {
+	return param(r, name, ParseUUID)
+}
+
+// ParamTime returns the value of the path parameter as a time, parsed with the given layout.
+func ParamTime(r *http.Request, name string, layout string) (time.Time, error) {
+	return param(r, name, func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	})
+}
@@ -0,0 +1,134 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestConstraints(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+
+	router.GET("/int/{v:int}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := mux.ParamInt(r, "v")
+		fmt.Fprint(w, v, err)
+	})
+	router.GET("/uint/{v:uint}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := mux.ParamUint(r, "v")
+		fmt.Fprint(w, v, err)
+	})
+	router.GET("/uuid/{v:uuid}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := mux.ParamUUID(r, "v")
+		fmt.Fprint(w, v, err)
+	})
+
+	tests := []struct {
+		path  string
+		code  int
+		body  string
+		trace string
+	}{
+		{"/int/-42", http.StatusOK, "-42 <nil>", "global"},
+		{"/int/abc", http.StatusNotFound, "404 page not found\n", "global"},
+		{"/uint/42", http.StatusOK, "42 <nil>", "global"},
+		{"/uint/-42", http.StatusNotFound, "404 page not found\n", "global"},
+		{"/uuid/0190F3A0-5B2C-7D4E-8F10-123456789ABC", http.StatusOK, "0190f3a0-5b2c-7d4e-8f10-123456789abc <nil>", "global"},
+		{"/uuid/0190f3a0-5b2c-7d4e-8f10", http.StatusNotFound, "404 page not found\n", "global"},
+		{"/uuid/0190f3a0-5b2c-7d4e-8f10-123456789xyz", http.StatusNotFound, "404 page not found\n", "global"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != tt.code {
+			t.Errorf("GET %q: status code expected: %d, got: %d", tt.path, tt.code, res.Code)
+		}
+		if got := res.Body.String(); got != tt.body {
+			t.Errorf("GET %q = %q; want %q", tt.path, got, tt.body)
+		}
+		if got := res.Header().Get("X-Trace"); got != tt.trace {
+			t.Errorf("GET %q: trace expected: %q, got: %q", tt.path, tt.trace, got)
+		}
+	}
+
+	// the registered pattern has no constraint
+	if got, want := router.Routes()[0].Pattern, "/int/{v}"; got != want {
+		t.Errorf("pattern expected: %q, got: %q", want, got)
+	}
+}
+
+func TestInvalidParam(t *testing.T) {
+	router := mux.NewRouter()
+	router.InvalidParam(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+	}))
+	router.GET("/status/{code:int}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/status/abc", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("status code expected: %d, got: %d", http.StatusBadRequest, res.Code)
+	}
+}
+
+func TestUnknownConstraint(t *testing.T) {
+	defer func() {
+		want := "unknown constraint: 'float'"
+		if r := recover(); r == nil {
+			t.Errorf("the code did not panic")
+		} else if msg := r.(string); msg != want {
+			t.Errorf("panic message expected: '%s', got: '%s'", want, msg)
+		}
+	}()
+
+	router := mux.NewRouter()
+	router.GET("/{v:float}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestTypedParams(t *testing.T) {
+	var (
+		i   int
+		u   uint
+		id  mux.UUID
+		day time.Time
+	)
+	errs := make(map[string]error)
+
+	router := mux.NewRouter()
+	router.GET("/{i}/{u}/{id}/{day}").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		i, errs["i"] = mux.ParamInt(r, "i")
+		u, errs["u"] = mux.ParamUint(r, "u")
+		id, errs["id"] = mux.ParamUUID(r, "id")
+		day, errs["day"] = mux.ParamTime(r, "day", time.DateOnly)
+	})
+
+	req := httptest.NewRequest("GET", "/-1/2/0190f3a0-5b2c-7d4e-8f10-123456789abc/2023-01-01", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	for name, err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", name, err)
+		}
+	}
+	if i != -1 || u != 2 || id.String() != "0190f3a0-5b2c-7d4e-8f10-123456789abc" || !day.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected values: %v, %v, %v, %v", i, u, id, day)
+	}
+
+	req = httptest.NewRequest("GET", "/a/-2/uuid/day", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	for _, name := range []string{"i", "u", "id", "day"} {
+		if errs[name] == nil {
+			t.Errorf("error expected for %s", name)
+		}
+	}
+	want := "invalid parameter 'i': strconv.Atoi: parsing \"a\": invalid syntax"
+	if got := errs["i"].Error(); got != want {
+		t.Errorf("error expected: %q, got: %q", want, got)
+	}
+}
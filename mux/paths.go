@@ -0,0 +1,227 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PathPolicy defines how the unclean paths (e.g. /a//b, /a/./b) and the paths
+// with a different trailing slash than the route (/a/ for /a, or /a for /a/)
+// are handled.
+type PathPolicy int
+
+const (
+	// PathDefault is the policy of http.ServeMux: the unclean paths, and the paths
+	// without trailing slash of the subtree routes, are redirected.
+	PathDefault PathPolicy = iota
+	// PathStrict serves the paths as they are, without cleaning nor redirect:
+	// /a and /a/ are different paths, and the unclean paths are not found.
+	PathStrict
+	// PathRedirect redirects the unclean paths and the paths with a different
+	// trailing slash to the path of the route, with 308 Permanent Redirect
+	// (the method and the body of the request are kept).
+	PathRedirect
+	// PathLenient serves the unclean paths and the paths with a different
+	// trailing slash as the path of the route, without redirect.
+	PathLenient
+)
+
+// PathOptions defines how the request paths are matched.
+type PathOptions struct {
+	// Policy is the policy of the unclean paths and of the trailing slashes.
+	Policy PathPolicy
+	// CaseInsensitive matches the literal segments of the routes case-insensitively.
+	// The path parameters and the path of the request keep their case.
+	CaseInsensitive bool
+	// EncodedSlashes keeps the encoded slashes (%2F) of the request path encoded:
+	// a path parameter can contain "%2F", distinct from "/".
+	EncodedSlashes bool
+}
+
+// Paths sets the path options of the router (shared by the router and its sub-routers).
+// It must be called before the routes are registered.
+//
+// Example:
+//
+//	router := mux.NewRouter()
+//	router.Paths(mux.PathOptions{Policy: mux.PathRedirect, CaseInsensitive: true})
+func (m *Mux) Paths(options PathOptions) *Mux {
+	m.update(func(t *table) {
+		if len(t.routes) > 0 {
+			panic("path options must be set before the routes are registered")
+		}
+		t.paths = options
+	})
+	return m
+}
+
+// preparePath applies the path options to the request, and reports whether
+// the request has been redirected.
+func (t *table) preparePath(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if t.paths.EncodedSlashes {
+		escaped := r.URL.EscapedPath()
+		if strings.Contains(escaped, "%2F") || strings.Contains(escaped, "%2f") {
+			escaped = strings.NewReplacer("%2F", "%252F", "%2f", "%252f").Replace(escaped)
+			r = withPath(r, escaped)
+		}
+	}
+
+	if (t.paths.Policy == PathRedirect || t.paths.Policy == PathLenient) && r.Method != http.MethodConnect && !t.resolves(t.fold(r)) {
+		if target, found := t.canonicalPath(r); found {
+			if t.paths.Policy == PathRedirect {
+				if q := r.URL.RawQuery; q != "" {
+					target += "?" + q
+				}
+				http.Redirect(w, r, target, http.StatusPermanentRedirect)
+				return r, true
+			}
+			r = withPath(r, target)
+		}
+	}
+
+	return t.fold(r), false
+}
+
+// canonicalPath returns the clean path, with or without trailing slash,
+// matching a route.
+func (t *table) canonicalPath(r *http.Request) (string, bool) {
+	escaped := r.URL.EscapedPath()
+	clean := cleanPath(escaped)
+	var candidates []string
+	if clean != escaped {
+		candidates = append(candidates, clean)
+	}
+	switch {
+	case clean == "/":
+	case strings.HasSuffix(clean, "/"):
+		candidates = append(candidates, strings.TrimSuffix(clean, "/"))
+	default:
+		candidates = append(candidates, clean+"/")
+	}
+	for _, candidate := range candidates {
+		if t.resolves(t.fold(withPath(r, candidate))) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// resolves reports whether a route matches the request path, for any method.
+// The redirects of the ServeMux don't match.
+func (t *table) resolves(r *http.Request) bool {
+	if h, _ := t.mux.Handler(r); isEntry(h) {
+		return true
+	}
+	return len(t.allowedMethods(r)) > 0
+}
+
+// fold returns the request with a lowercase path if the matching is case-insensitive.
+// The original URL is restored by the entry matching the request (see entry.restore).
+func (t *table) fold(r *http.Request) *http.Request {
+	if !t.paths.CaseInsensitive {
+		return r
+	}
+	escaped := r.URL.EscapedPath()
+	lower := strings.ToLower(escaped)
+	if lower == escaped {
+		return r
+	}
+	original := r.URL
+	r = withPath(r, lower)
+	return r.WithContext(context.WithValue(r.Context(), pathContextKey, original))
+}
+
+// restore restores the original host of a request (see foldHost), the original
+// URL of a request matched case-insensitively, and the original case of its
+// path parameters.
+func (e *entry) restore(r *http.Request) *http.Request {
+	if host, ok := r.Context().Value(hostContextKey).(string); ok && host != "" {
+		r = r.WithContext(context.WithValue(r.Context(), hostContextKey, ""))
+		r.Host = host
+	}
+	original, ok := r.Context().Value(pathContextKey).(*url.URL)
+	if !ok {
+		return r
+	}
+	r = r.WithContext(context.WithValue(r.Context(), pathContextKey, nil))
+	r.URL = original
+	segments := strings.Split(strings.TrimPrefix(original.EscapedPath(), "/"), "/")
+	for i, name := range e.wildcards {
+		if name == "" || i >= len(segments) {
+			continue
+		}
+		value := segments[i]
+		if rest, ok := strings.CutSuffix(name, "..."); ok {
+			name, value = rest, strings.Join(segments[i:], "/")
+		}
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		r.SetPathValue(name, value)
+	}
+	return r
+}
+
+// wildcards returns the wildcard names of the pattern path by segment index
+// (with "..." for the remaining segments wildcard), or empty strings.
+func wildcards(path string) []string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	names := make([]string, len(segments))
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, "{"); ok && segment != "{$}" {
+			names[i] = strings.TrimSuffix(name, "}")
+		}
+	}
+	return names
+}
+
+// foldPattern returns the pattern path with lowercase literal segments.
+func foldPattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			segments[i] = strings.ToLower(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isEntry reports whether the handler returned by the ServeMux is a route entry
+// (and not a redirect or an error handler).
+func isEntry(h http.Handler) bool {
+	_, ok := h.(*entry)
+	return ok
+}
+
+// cleanPath returns the canonical path, like http.ServeMux: the . and .. elements
+// and the repeated slashes are removed, and the trailing slash is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	clean := path.Clean(p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return clean
+}
+
+// withPath returns a shallow copy of the request with the given escaped path.
+func withPath(r *http.Request, escaped string) *http.Request {
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		return r
+	}
+	r2 := r.WithContext(r.Context())
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = unescaped
+	r2.URL.RawPath = escaped
+	return r2
+}
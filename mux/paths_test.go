@@ -0,0 +1,126 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func pathsRouter(options mux.PathOptions) *mux.Mux {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Pattern, r.URL.Path)
+		if name := r.PathValue("name"); name != "" {
+			fmt.Fprintf(w, " name=%s", name)
+		}
+	}
+	router := mux.NewRouter()
+	router.Paths(options)
+	router.GET("/a").ThenFunc(echo)
+	router.GET("/dir/").ThenFunc(echo)
+	router.POST("/items").ThenFunc(echo)
+	router.GET("/Users/{name}").ThenFunc(echo)
+	router.GET("/files/{name...}").ThenFunc(echo)
+	return router
+}
+
+func TestPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  mux.PathOptions
+		method   string
+		target   string
+		code     int
+		location string
+		body     string
+	}{
+		// default: http.ServeMux behavior
+		{"default exact", mux.PathOptions{}, "GET", "/a", 200, "", "GET /a /a"},
+		{"default unclean", mux.PathOptions{}, "GET", "/x/../a", 307, "/a", ""},
+		{"default subtree without slash", mux.PathOptions{}, "GET", "/dir", 307, "/dir/", ""},
+		{"default extra slash", mux.PathOptions{}, "GET", "/a/", 404, "", ""},
+
+		// strict
+		{"strict exact", mux.PathOptions{Policy: mux.PathStrict}, "GET", "/a", 200, "", "GET /a /a"},
+		{"strict unclean", mux.PathOptions{Policy: mux.PathStrict}, "GET", "/x/../a", 404, "", ""},
+		{"strict double slash", mux.PathOptions{Policy: mux.PathStrict}, "GET", "//a", 404, "", ""},
+		{"strict subtree without slash", mux.PathOptions{Policy: mux.PathStrict}, "GET", "/dir", 404, "", ""},
+		{"strict extra slash", mux.PathOptions{Policy: mux.PathStrict}, "GET", "/a/", 404, "", ""},
+
+		// redirect
+		{"redirect exact", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/a", 200, "", "GET /a /a"},
+		{"redirect unclean", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/x/../a?q=1", 308, "/a?q=1", ""},
+		{"redirect extra slash", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/a/", 308, "/a", ""},
+		{"redirect missing slash", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/dir", 308, "/dir/", ""},
+		{"redirect unclean and slash", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "//dir", 308, "/dir/", ""},
+		{"redirect keeps method", mux.PathOptions{Policy: mux.PathRedirect}, "POST", "/items/", 308, "/items", ""},
+		{"redirect to 405", mux.PathOptions{Policy: mux.PathRedirect}, "DELETE", "/items/", 308, "/items", ""},
+		{"redirect unknown", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/b/", 404, "", ""},
+		{"redirect root", mux.PathOptions{Policy: mux.PathRedirect}, "GET", "/", 404, "", ""},
+
+		// lenient
+		{"lenient unclean", mux.PathOptions{Policy: mux.PathLenient}, "GET", "/x/./../a", 200, "", "GET /a /a"},
+		{"lenient extra slash", mux.PathOptions{Policy: mux.PathLenient}, "GET", "/a/", 200, "", "GET /a /a"},
+		{"lenient missing slash", mux.PathOptions{Policy: mux.PathLenient}, "GET", "/dir", 200, "", "GET /dir/ /dir/"},
+		{"lenient post", mux.PathOptions{Policy: mux.PathLenient}, "POST", "/items/", 200, "", "POST /items /items"},
+		{"lenient wrong method", mux.PathOptions{Policy: mux.PathLenient}, "PUT", "/items/", 405, "", ""},
+
+		// case-insensitive
+		{"case exact", mux.PathOptions{CaseInsensitive: true}, "GET", "/Users/Bob", 200, "", "GET /users/{name} /Users/Bob name=Bob"},
+		{"case folded", mux.PathOptions{CaseInsensitive: true}, "GET", "/USERS/Bob", 200, "", "GET /users/{name} /USERS/Bob name=Bob"},
+		{"case rest wildcard", mux.PathOptions{CaseInsensitive: true}, "GET", "/FILES/Docs/A.txt", 200, "", "GET /files/{name...} /FILES/Docs/A.txt name=Docs/A.txt"},
+		{"case sensitive", mux.PathOptions{}, "GET", "/USERS/Bob", 404, "", ""},
+		{"case with redirect", mux.PathOptions{Policy: mux.PathRedirect, CaseInsensitive: true}, "GET", "/A/", 308, "/A", ""},
+		{"case with lenient", mux.PathOptions{Policy: mux.PathLenient, CaseInsensitive: true}, "GET", "/Users//Bob", 200, "", "GET /users/{name} /Users/Bob name=Bob"},
+
+		// encoded slashes
+		{"encoded slash decoded", mux.PathOptions{}, "GET", "/Users/a%2Fb", 200, "", "GET /Users/{name} /Users/a/b name=a/b"},
+		{"encoded slash kept", mux.PathOptions{EncodedSlashes: true}, "GET", "/Users/a%2Fb", 200, "", "GET /Users/{name} /Users/a%2Fb name=a%2Fb"},
+		{"encoded slash kept in rest", mux.PathOptions{EncodedSlashes: true}, "GET", "/files/a%2fb/c", 200, "", "GET /files/{name...} /files/a%2fb/c name=a%2fb/c"},
+		{"encoded slash and case", mux.PathOptions{EncodedSlashes: true, CaseInsensitive: true}, "GET", "/USERS/A%2FB", 200, "", "GET /users/{name} /USERS/A%2FB name=A%2FB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := pathsRouter(tt.options)
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("%s %s: status code expected: %d, got: %d (%s)", tt.method, tt.target, tt.code, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("%s %s: location expected: %q, got: %q", tt.method, tt.target, tt.location, got)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("%s %s: body expected: %q, got: %q", tt.method, tt.target, tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPathsAfterRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/a").ThenFunc(func(http.ResponseWriter, *http.Request) {})
+	defer func() {
+		if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "before the routes") {
+			t.Errorf("panic expected, got: %v", v)
+		}
+	}()
+	router.Paths(mux.PathOptions{Policy: mux.PathStrict})
+}
+
+func TestPathsCaseConflict(t *testing.T) {
+	router := mux.NewRouter()
+	router.Paths(mux.PathOptions{CaseInsensitive: true})
+	router.GET("/a").ThenFunc(func(http.ResponseWriter, *http.Request) {})
+	defer func() {
+		if v := recover(); v == nil {
+			t.Error("panic expected for a route differing only by case")
+		}
+	}()
+	router.GET("/A").ThenFunc(func(http.ResponseWriter, *http.Request) {})
+}
@@ -0,0 +1,801 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/carlito767/go-stack/internal/yaml"
+)
+
+// Spec is an OpenAPI 3 document, used to validate the requests and the responses
+// against the operations it describes.
+//
+// The requests to the paths (or with the methods) not described by the document
+// are not validated. The schemas support the keywords of OpenAPI 3.0 and 3.1
+// related to validation, except the formats other than date-time, date and uuid.
+//
+// Example:
+//
+//	spec, err := mux.LoadSpec(specData)
+//	...
+//	router.Use(spec.ValidateRequests)
+type Spec struct {
+	// MaxBodySize is the maximum size of the validated bodies, in bytes (1 MB by default).
+	MaxBodySize int64
+
+	doc      map[string]any
+	bases    []string
+	paths    []*specPath
+	patterns sync.Map // string -> *regexp.Regexp
+}
+
+// specPath is a path of the document.
+type specPath struct {
+	template string
+	re       *regexp.Regexp
+	names    []string
+	item     map[string]any
+}
+
+// ValidationError is a violation of the specification.
+type ValidationError struct {
+	// In is the location of the violation: path, query, header, cookie, body or status.
+	In string `json:"in"`
+	// Name is the name of the parameter or header, or the JSON pointer of the value in the body.
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors are the violations of the specification by a request or a response.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		if err.Name == "" {
+			messages[i] = fmt.Sprintf("%s: %s", err.In, err.Message)
+		} else {
+			messages[i] = fmt.Sprintf("%s '%s': %s", err.In, err.Name, err.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// StatusCode implements the StatusCoder interface.
+func (e ValidationErrors) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// LoadSpec loads an OpenAPI 3 document, in JSON or YAML.
+//
+// Only the subset of YAML used by the specifications is supported: block mappings
+// and sequences, flow collections ([a, b] and {a: b}), plain, single-quoted and
+// double-quoted scalars on a single line (with the escape sequences of YAML 1.2),
+// literal (|) and folded (>) block scalars, and comments. Anchors, aliases, tags,
+// multi-line quoted scalars and multiple documents are not supported.
+func LoadSpec(data []byte) (*Spec, error) {
+	var v any
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, &v)
+	} else {
+		v, err = yaml.Parse(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	doc, _ := v.(map[string]any)
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, errors.New("invalid OpenAPI document: unsupported version")
+	}
+
+	s := &Spec{MaxBodySize: 1 << 20, doc: doc}
+	servers, _ := doc["servers"].([]any)
+	for _, server := range servers {
+		server, _ := server.(map[string]any)
+		if u, err := url.Parse(fmt.Sprint(server["url"])); err == nil {
+			s.bases = append(s.bases, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+	if len(s.bases) == 0 {
+		s.bases = []string{""}
+	}
+	// The longest base paths are tried first.
+	slices.SortFunc(s.bases, func(a, b string) int { return len(b) - len(a) })
+
+	paths, _ := doc["paths"].(map[string]any)
+	for template, item := range paths {
+		item, _ := s.resolve(item).(map[string]any)
+		p := &specPath{template: template, item: item}
+		var expr strings.Builder
+		expr.WriteByte('^')
+		rest := template
+		for {
+			i := strings.IndexByte(rest, '{')
+			j := strings.IndexByte(rest, '}')
+			if i < 0 || j < i {
+				break
+			}
+			expr.WriteString(regexp.QuoteMeta(rest[:i]))
+			expr.WriteString("([^/]+)")
+			p.names = append(p.names, rest[i+1:j])
+			rest = rest[j+1:]
+		}
+		expr.WriteString(regexp.QuoteMeta(rest))
+		expr.WriteByte('$')
+		p.re, err = regexp.Compile(expr.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenAPI document: path '%s': %w", template, err)
+		}
+		s.paths = append(s.paths, p)
+	}
+	// The paths without templates take precedence (e.g. /users/me over /users/{id}).
+	slices.SortFunc(s.paths, func(a, b *specPath) int {
+		if len(a.names) != len(b.names) {
+			return len(a.names) - len(b.names)
+		}
+		return strings.Compare(a.template, b.template)
+	})
+	return s, nil
+}
+
+// operation returns the path item and the operation of the request, with its path parameters.
+func (s *Spec) operation(r *http.Request) (map[string]any, map[string]any, map[string]string) {
+	escaped := r.URL.EscapedPath()
+	for _, base := range s.bases {
+		rest, ok := strings.CutPrefix(escaped, base)
+		if !ok || rest != "" && rest[0] != '/' {
+			continue
+		}
+		for _, p := range s.paths {
+			values := p.re.FindStringSubmatch(rest)
+			if values == nil {
+				continue
+			}
+			method := strings.ToLower(r.Method)
+			op, _ := s.resolve(p.item[method]).(map[string]any)
+			if op == nil && method == "head" {
+				op, _ = s.resolve(p.item["get"]).(map[string]any)
+			}
+			if op == nil {
+				return nil, nil, nil
+			}
+			params := make(map[string]string, len(p.names))
+			for i, name := range p.names {
+				if v, err := url.PathUnescape(values[i+1]); err == nil {
+					params[name] = v
+				} else {
+					params[name] = values[i+1]
+				}
+			}
+			return p.item, op, params
+		}
+	}
+	return nil, nil, nil
+}
+
+// ValidateRequest validates the request against the specification.
+// The returned error, if any, is a ValidationErrors.
+// The body of the request is read and replaced by a copy.
+func (s *Spec) ValidateRequest(r *http.Request) error {
+	item, op, pathParams := s.operation(r)
+	if op == nil {
+		return nil
+	}
+	var errs ValidationErrors
+
+	params := map[string]map[string]any{}
+	var order []string
+	for _, list := range []any{item["parameters"], op["parameters"]} {
+		list, _ := list.([]any)
+		for _, param := range list {
+			param, _ := s.resolve(param).(map[string]any)
+			key := fmt.Sprint(param["in"]) + ":" + fmt.Sprint(param["name"])
+			if _, found := params[key]; !found {
+				order = append(order, key)
+			}
+			params[key] = param // the operation parameters override the path item parameters
+		}
+	}
+	for _, key := range order {
+		param := params[key]
+		in, _ := param["in"].(string)
+		name, _ := param["name"].(string)
+		var values []string
+		switch in {
+		case "path":
+			if v, found := pathParams[name]; found {
+				values = []string{v}
+			}
+		case "query":
+			values = r.URL.Query()[name]
+		case "header":
+			values = r.Header.Values(name)
+		case "cookie":
+			if c, err := r.Cookie(name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+		if len(values) == 0 {
+			if required, _ := param["required"].(bool); required || in == "path" {
+				errs = append(errs, ValidationError{in, name, "missing required parameter"})
+			}
+			continue
+		}
+		schema := param["schema"]
+		explode := in == "query" || in == "cookie"
+		if v, ok := param["explode"].(bool); ok {
+			explode = v
+		}
+		for _, err := range s.check(s.coerce(values, schema, explode), schema, "") {
+			errs = append(errs, ValidationError{in, name + err.Name, err.Message})
+		}
+	}
+
+	if body, _ := s.resolve(op["requestBody"]).(map[string]any); body != nil {
+		errs = append(errs, s.checkBody(r, body)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkBody validates the body of the request. A request without body
+// (nil or http.NoBody) has an empty body.
+func (s *Spec) checkBody(r *http.Request, body map[string]any) ValidationErrors {
+	var data []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(r.Body, s.MaxBodySize+1))
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return ValidationErrors{{"body", "", "unreadable body"}}
+		}
+	}
+	if int64(len(data)) > s.MaxBodySize {
+		return ValidationErrors{{"body", "", "body too large"}}
+	}
+	if len(data) == 0 {
+		if required, _ := body["required"].(bool); required {
+			return ValidationErrors{{"body", "", "missing required body"}}
+		}
+		return nil
+	}
+	content, _ := body["content"].(map[string]any)
+	return s.checkContent(r.Header.Get("Content-Type"), data, content)
+}
+
+// checkContent validates the body with the schema of its media type.
+// Only the JSON bodies are validated against their schema.
+func (s *Spec) checkContent(contentType string, data []byte, content map[string]any) ValidationErrors {
+	if len(content) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ValidationErrors{{"header", "Content-Type", "missing or invalid content type"}}
+	}
+	media, found := s.media(content, mediaType)
+	if !found {
+		return ValidationErrors{{"header", "Content-Type", fmt.Sprintf("unsupported content type: '%s'", mediaType)}}
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return ValidationErrors{{"body", "", fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	if dec.More() {
+		return ValidationErrors{{"body", "", "invalid JSON: unexpected data after the JSON value"}}
+	}
+	var errs ValidationErrors
+	for _, err := range s.check(v, media["schema"], "") {
+		errs = append(errs, ValidationError{"body", err.Name, err.Message})
+	}
+	return errs
+}
+
+// media returns the media type object matching the media type.
+func (s *Spec) media(content map[string]any, mediaType string) (map[string]any, bool) {
+	ranges := make([]string, 0, len(content))
+	for mediaRange := range content {
+		ranges = append(ranges, mediaRange)
+	}
+	// The most specific media ranges are tried first.
+	slices.SortFunc(ranges, func(a, b string) int {
+		if c := strings.Count(b, "*") - strings.Count(a, "*"); c != 0 {
+			return -c
+		}
+		return strings.Compare(a, b)
+	})
+	for _, mediaRange := range ranges {
+		name, _, err := mime.ParseMediaType(mediaRange)
+		if err == nil && matchMediaType(name, mediaType) {
+			media, _ := s.resolve(content[mediaRange]).(map[string]any)
+			return media, true
+		}
+	}
+	return nil, false
+}
+
+// ValidateResponse validates the response to the request against the specification.
+// The returned error, if any, is a ValidationErrors.
+func (s *Spec) ValidateResponse(r *http.Request, status int, header http.Header, body []byte) error {
+	_, op, _ := s.operation(r)
+	if op == nil {
+		return nil
+	}
+	responses, _ := op["responses"].(map[string]any)
+	code := strconv.Itoa(status)
+	response, found := responses[code]
+	if !found {
+		response, found = responses[code[:1]+"XX"]
+	}
+	if !found {
+		response, found = responses["default"]
+	}
+	if !found {
+		return ValidationErrors{{"status", code, "undocumented status code"}}
+	}
+	resp, _ := s.resolve(response).(map[string]any)
+
+	var errs ValidationErrors
+	headers, _ := resp["headers"].(map[string]any)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		h, _ := s.resolve(headers[name]).(map[string]any)
+		values := header.Values(name)
+		if len(values) == 0 {
+			if required, _ := h["required"].(bool); required {
+				errs = append(errs, ValidationError{"header", name, "missing required header"})
+			}
+			continue
+		}
+		for _, err := range s.check(s.coerce(values, h["schema"], false), h["schema"], "") {
+			errs = append(errs, ValidationError{"header", name + err.Name, err.Message})
+		}
+	}
+
+	content, _ := resp["content"].(map[string]any)
+	switch {
+	case len(body) == 0:
+	case len(content) == 0:
+		errs = append(errs, ValidationError{"body", "", "undocumented body"})
+	default:
+		errs = append(errs, s.checkContent(header.Get("Content-Type"), body, content)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateRequests is a middleware rejecting the requests violating the specification
+// before they reach the handler, with a 400 Bad Request problem details
+// response (RFC 9457) listing the violations in its "errors" member.
+func (s *Spec) ValidateRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := s.ValidateRequest(r)
+		var errs ValidationErrors
+		if !errors.As(err, &errs) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(struct {
+			Type   string           `json:"type"`
+			Title  string           `json:"title"`
+			Status int              `json:"status"`
+			Detail string           `json:"detail"`
+			Errors ValidationErrors `json:"errors"`
+		}{"about:blank", http.StatusText(http.StatusBadRequest), http.StatusBadRequest, "the request does not match the API specification", errs})
+	})
+}
+
+// ValidateResponses returns a middleware validating the responses against the
+// specification, and reporting the violations (e.g. with testing.T.Error).
+// The responses are buffered, so the middleware is meant for the tests.
+func (s *Spec) ValidateResponses(report func(*http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &recordingResponseWriter{header: make(http.Header)}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if err := s.ValidateResponse(r, rec.status, rec.header, rec.body.Bytes()); err != nil {
+				report(r, err)
+			}
+			for key, values := range rec.header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// recordingResponseWriter buffers a response.
+type recordingResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// resolve returns the value referenced by a reference object ({"$ref": "#/..."}),
+// or the value itself.
+func (s *Spec) resolve(v any) any {
+	for range 32 { // references to references, without cycle
+		obj, _ := v.(map[string]any)
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return v
+		}
+		var target any = s.doc
+		for _, token := range strings.Split(strings.TrimPrefix(ref[1:], "/"), "/") {
+			if token == "" {
+				continue
+			}
+			if unescaped, err := url.PathUnescape(token); err == nil {
+				token = unescaped
+			}
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			m, _ := target.(map[string]any)
+			target = m[token]
+		}
+		v = target
+	}
+	return v
+}
+
+// coerce converts the values of a parameter to the types of its schema.
+func (s *Spec) coerce(values []string, schema any, explode bool) any {
+	sch, _ := s.resolve(schema).(map[string]any)
+	if schemaType(sch) == "array" {
+		if !explode && len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]any, len(values))
+		for i, value := range values {
+			items[i] = s.coerce([]string{value}, sch["items"], explode)
+		}
+		return items
+	}
+
+	value := values[0]
+	switch schemaType(sch) {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// schemaType returns the first non-null type of the schema, or an empty string.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if v != "null" {
+				return fmt.Sprint(v)
+			}
+		}
+	}
+	return ""
+}
+
+// schemaError is a violation of a schema.
+// Name is the JSON pointer of the value (or the index of a parameter item).
+type schemaError struct {
+	Name    string
+	Message string
+}
+
+// check validates the value against the schema.
+func (s *Spec) check(v any, schema any, pointer string) []schemaError {
+	switch sch := s.resolve(schema).(type) {
+	case nil:
+		return nil
+	case bool:
+		if !sch {
+			return []schemaError{{pointer, "not allowed"}}
+		}
+		return nil
+	case map[string]any:
+		return s.checkSchema(v, sch, pointer)
+	}
+	return nil
+}
+
+func (s *Spec) checkSchema(v any, sch map[string]any, pointer string) []schemaError {
+	var errs []schemaError
+	fail := func(format string, args ...any) {
+		errs = append(errs, schemaError{pointer, fmt.Sprintf(format, args...)})
+	}
+
+	// Keywords beside $ref are allowed by OpenAPI 3.1.
+	if ref, ok := sch["$ref"]; ok {
+		errs = append(errs, s.check(v, map[string]any{"$ref": ref}, pointer)...)
+	}
+	if nullable, _ := sch["nullable"].(bool); nullable && v == nil {
+		return errs
+	}
+
+	if t, ok := sch["type"]; ok {
+		types, ok := t.([]any)
+		if !ok {
+			types = []any{t}
+		}
+		if !slices.ContainsFunc(types, func(t any) bool { return hasType(v, fmt.Sprint(t)) }) {
+			names := make([]string, len(types))
+			for i, t := range types {
+				names[i] = fmt.Sprint(t)
+			}
+			fail("must be %s", strings.Join(names, " or "))
+			return errs
+		}
+	}
+	if enum, ok := sch["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return equalJSON(v, e) }) {
+		fail("must be one of %s", marshalJSON(enum))
+	}
+	if c, ok := sch["const"]; ok && !equalJSON(v, c) {
+		fail("must be %s", marshalJSON(c))
+	}
+
+	switch v := v.(type) {
+	case json.Number, float64:
+		n, _ := toFloat(v)
+		if min, ok := toFloat(sch["minimum"]); ok {
+			if exclusive, _ := sch["exclusiveMinimum"].(bool); exclusive && n <= min {
+				fail("must be greater than %v", min)
+			} else if n < min {
+				fail("must be greater than or equal to %v", min)
+			}
+		}
+		if max, ok := toFloat(sch["maximum"]); ok {
+			if exclusive, _ := sch["exclusiveMaximum"].(bool); exclusive && n >= max {
+				fail("must be less than %v", max)
+			} else if n > max {
+				fail("must be less than or equal to %v", max)
+			}
+		}
+		if min, ok := toFloat(sch["exclusiveMinimum"]); ok && n <= min {
+			fail("must be greater than %v", min)
+		}
+		if max, ok := toFloat(sch["exclusiveMaximum"]); ok && n >= max {
+			fail("must be less than %v", max)
+		}
+		if m, ok := toFloat(sch["multipleOf"]); ok && m > 0 {
+			if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("must be a multiple of %v", m)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if min, ok := toFloat(sch["minLength"]); ok && length < min {
+			fail("must be at least %v characters long", min)
+		}
+		if max, ok := toFloat(sch["maxLength"]); ok && length > max {
+			fail("must be at most %v characters long", max)
+		}
+		if pattern, ok := sch["pattern"].(string); ok {
+			if re, err := s.pattern(pattern); err == nil && !re.MatchString(v) {
+				fail("must match the pattern '%s'", pattern)
+			}
+		}
+		if format, ok := sch["format"].(string); ok && !checkFormat(v, format) {
+			fail("must be a valid %s", format)
+		}
+	case []any:
+		length := float64(len(v))
+		if min, ok := toFloat(sch["minItems"]); ok && length < min {
+			fail("must have at least %v items", min)
+		}
+		if max, ok := toFloat(sch["maxItems"]); ok && length > max {
+			fail("must have at most %v items", max)
+		}
+		if unique, _ := sch["uniqueItems"].(bool); unique {
+			for i := range v {
+				if slices.ContainsFunc(v[:i], func(e any) bool { return equalJSON(v[i], e) }) {
+					fail("must have unique items")
+					break
+				}
+			}
+		}
+		if items, ok := sch["items"]; ok {
+			for i, item := range v {
+				errs = append(errs, s.check(item, items, pointer+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case map[string]any:
+		length := float64(len(v))
+		if min, ok := toFloat(sch["minProperties"]); ok && length < min {
+			fail("must have at least %v properties", min)
+		}
+		if max, ok := toFloat(sch["maxProperties"]); ok && length > max {
+			fail("must have at most %v properties", max)
+		}
+		required, _ := sch["required"].([]any)
+		for _, name := range required {
+			if _, found := v[fmt.Sprint(name)]; !found {
+				errs = append(errs, schemaError{pointer + "/" + escapePointer(fmt.Sprint(name)), "missing required property"})
+			}
+		}
+		properties, _ := sch["properties"].(map[string]any)
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			property, found := properties[name]
+			if !found {
+				additional, ok := sch["additionalProperties"]
+				if !ok {
+					continue
+				}
+				property = additional
+			}
+			errs = append(errs, s.check(v[name], property, pointer+"/"+escapePointer(name))...)
+		}
+	}
+
+	if allOf, ok := sch["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			errs = append(errs, s.check(v, sub, pointer)...)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]any); ok {
+		if !slices.ContainsFunc(anyOf, func(sub any) bool { return len(s.check(v, sub, pointer)) == 0 }) {
+			fail("must match at least one schema (anyOf)")
+		}
+	}
+	if oneOf, ok := sch["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if len(s.check(v, sub, pointer)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("must match exactly one schema (oneOf)")
+		}
+	}
+	if not, ok := sch["not"]; ok && len(s.check(v, not, pointer)) == 0 {
+		fail("must not match the schema (not)")
+	}
+	return errs
+}
+
+// pattern returns the compiled regular expression of the pattern.
+func (s *Spec) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns.Store(pattern, re)
+	return re, nil
+}
+
+// hasType reports whether the value has the JSON Schema type.
+func hasType(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		n, ok := toFloat(v)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	}
+	return true
+}
+
+// checkFormat reports whether the string has the format.
+// The unknown formats are not checked.
+func checkFormat(v string, format string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, v)
+	case "date":
+		_, err = time.Parse(time.DateOnly, v)
+	case "uuid":
+		_, err = ParseUUID(v)
+	}
+	return err == nil
+}
+
+// toFloat returns the number as a float64.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// equalJSON reports whether the JSON values are equal.
+func equalJSON(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return marshalJSON(a) == marshalJSON(b)
+}
+
+func marshalJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// escapePointer escapes a JSON pointer token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
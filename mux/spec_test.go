@@ -0,0 +1,378 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+const specYAML = `
+openapi: 3.1.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://example.com/api
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, minimum: 1, maximum: 100}
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [cat, dog]
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      parameters:
+        - $ref: '#/components/parameters/RequestID'
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+      responses:
+        '201':
+          description: Created
+          headers:
+            Location:
+              required: true
+              schema: {type: string}
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          format: uuid
+    get:
+      responses:
+        '200':
+          description: OK
+        4XX:
+          description: Client error
+  /pets/mine:
+    get:
+      responses:
+        default:
+          description: Anything
+components:
+  parameters:
+    RequestID:
+      name: X-Request-Id
+      in: header
+      required: true
+      schema:
+        type: string
+        pattern: '^[a-z0-9-]+$' # lowercase
+  schemas:
+    NewPet:
+      type: object
+      description: >
+        A pet, before
+        its creation.
+      required: [name]
+      additionalProperties: false
+      properties:
+        name:
+          type: string
+          minLength: 1
+        birth:
+          type: string
+          format: date
+        weight:
+          type: [number, "null"]
+          exclusiveMinimum: 0
+    Pet:
+      type: object
+      required:
+        - id
+        - name
+      properties:
+        id: {type: string, format: uuid}
+        name:
+          $ref: '#/components/schemas/NewPet/properties/name'
+`
+
+func TestSpecValidateRequests(t *testing.T) {
+	spec, err := mux.LoadSpec([]byte(specYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	router.Use(spec.ValidateRequests)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	router.GET("/api/pets").Then(h)
+	router.POST("/api/pets").Then(h)
+	router.GET("/api/pets/{id}").Then(h)
+	router.GET("/api/other").Then(h)
+
+	const id = "0191e9a0-8b2a-7c3e-9d4f-5a6b7c8d9e0f"
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header map[string]string
+		body   string
+		errors []mux.ValidationError
+	}{
+		{name: "valid query", method: "GET", target: "/api/pets?limit=10&tags=cat&tags=dog"},
+		{name: "no query", method: "GET", target: "/api/pets"},
+		{
+			name:   "invalid query",
+			method: "GET",
+			target: "/api/pets?limit=abc&tags=cow",
+			errors: []mux.ValidationError{
+				{In: "query", Name: "limit", Message: "must be integer"},
+				{In: "query", Name: "tags/0", Message: `must be one of ["cat","dog"]`},
+			},
+		},
+		{
+			name:   "out of range query",
+			method: "GET",
+			target: "/api/pets?limit=101",
+			errors: []mux.ValidationError{{In: "query", Name: "limit", Message: "must be less than or equal to 100"}},
+		},
+		{name: "valid path", method: "GET", target: "/api/pets/" + id},
+		{
+			name:   "invalid path",
+			method: "GET",
+			target: "/api/pets/42",
+			errors: []mux.ValidationError{{In: "path", Name: "id", Message: "must be a valid uuid"}},
+		},
+		{name: "literal path over template", method: "GET", target: "/api/pets/mine"},
+		{name: "undocumented path", method: "GET", target: "/api/other"},
+		{
+			name:   "valid body",
+			method: "POST",
+			target: "/api/pets",
+			header: map[string]string{"Content-Type": "application/json", "X-Request-Id": "abc-123"},
+			body:   `{"name":"Rex","birth":"2020-01-31","weight":null}`,
+		},
+		{
+			name:   "invalid body",
+			method: "POST",
+			target: "/api/pets",
+			header: map[string]string{"Content-Type": "application/json", "X-Request-Id": "ABC"},
+			body:   `{"name":"","birth":"yesterday","weight":0,"owner":"me"}`,
+			errors: []mux.ValidationError{
+				{In: "header", Name: "X-Request-Id", Message: "must match the pattern '^[a-z0-9-]+$'"},
+				{In: "body", Name: "/birth", Message: "must be a valid date"},
+				{In: "body", Name: "/name", Message: "must be at least 1 characters long"},
+				{In: "body", Name: "/owner", Message: "not allowed"},
+				{In: "body", Name: "/weight", Message: "must be greater than 0"},
+			},
+		},
+		{
+			name:   "missing body",
+			method: "POST",
+			target: "/api/pets",
+			errors: []mux.ValidationError{
+				{In: "header", Name: "X-Request-Id", Message: "missing required parameter"},
+				{In: "body", Message: "missing required body"},
+			},
+		},
+		{
+			name:   "missing property",
+			method: "POST",
+			target: "/api/pets",
+			header: map[string]string{"Content-Type": "application/json", "X-Request-Id": "abc"},
+			body:   `{}`,
+			errors: []mux.ValidationError{{In: "body", Name: "/name", Message: "missing required property"}},
+		},
+		{
+			name:   "syntax error",
+			method: "POST",
+			target: "/api/pets",
+			header: map[string]string{"Content-Type": "application/json", "X-Request-Id": "abc"},
+			body:   `{"name":`,
+			errors: []mux.ValidationError{{In: "body", Message: "invalid JSON: unexpected EOF"}},
+		},
+		{
+			name:   "unsupported content type",
+			method: "POST",
+			target: "/api/pets",
+			header: map[string]string{"Content-Type": "text/plain", "X-Request-Id": "abc"},
+			body:   `name=Rex`,
+			errors: []mux.ValidationError{{In: "header", Name: "Content-Type", Message: "unsupported content type: 'text/plain'"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+
+			if tt.errors == nil {
+				if res.StatusCode != http.StatusOK {
+					t.Fatalf("status code: got %d, want %d (%s)", res.StatusCode, http.StatusOK, body)
+				}
+				if string(body) != tt.body {
+					t.Errorf("body: got '%s', want '%s'", body, tt.body)
+				}
+				return
+			}
+			if res.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status code: got %d, want %d", res.StatusCode, http.StatusBadRequest)
+			}
+			if got := res.Header.Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("content type: got '%s', want 'application/problem+json'", got)
+			}
+			var problem struct {
+				Status int                   `json:"status"`
+				Errors []mux.ValidationError `json:"errors"`
+			}
+			if err := json.Unmarshal(body, &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Status != http.StatusBadRequest {
+				t.Errorf("status: got %d, want %d", problem.Status, http.StatusBadRequest)
+			}
+			if len(problem.Errors) != len(tt.errors) {
+				t.Fatalf("errors: got %v, want %v", problem.Errors, tt.errors)
+			}
+			for i, err := range tt.errors {
+				if problem.Errors[i] != err {
+					t.Errorf("error %d: got %v, want %v", i, problem.Errors[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestSpecValidateRequestWithoutBody(t *testing.T) {
+	spec, err := mux.LoadSpec([]byte(specYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []io.Reader{nil, http.NoBody} {
+		req, err := http.NewRequest("POST", "/api/pets", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Request-Id", "abc")
+		var errs mux.ValidationErrors
+		if err := spec.ValidateRequest(req); !errors.As(err, &errs) || len(errs) != 1 || errs[0] != (mux.ValidationError{In: "body", Message: "missing required body"}) {
+			t.Errorf("body %v: got %v, want missing required body", body, err)
+		}
+	}
+}
+
+func TestSpecValidateResponse(t *testing.T) {
+	spec, err := mux.LoadSpec([]byte(specYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const id = "0191e9a0-8b2a-7c3e-9d4f-5a6b7c8d9e0f"
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		header http.Header
+		body   string
+		err    string
+	}{
+		{"valid", "GET", "/api/pets", 200, http.Header{"Content-Type": {"application/json"}}, `[{"id":"` + id + `","name":"Rex"}]`, ""},
+		{"invalid body", "GET", "/api/pets", 200, http.Header{"Content-Type": {"application/json"}}, `[{"name":"Rex"}]`, "body '/0/id': missing required property"},
+		{"undocumented status code", "GET", "/api/pets", 500, nil, ``, "status '500': undocumented status code"},
+		{"status code range", "GET", "/api/pets/" + id, 404, nil, ``, ""},
+		{"default response", "GET", "/api/pets/mine", 500, nil, ``, ""},
+		{"undocumented body", "GET", "/api/pets/" + id, 200, http.Header{"Content-Type": {"text/plain"}}, `hello`, "body: undocumented body"},
+		{"missing header", "POST", "/api/pets", 201, nil, ``, "header 'Location': missing required header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			err := spec.ValidateResponse(req, tt.status, header, []byte(tt.body))
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("error: got '%v', want '%s'", err, tt.err)
+			}
+		})
+	}
+
+	var reported []error
+	router := mux.NewRouter()
+	router.Use(spec.ValidateResponses(func(r *http.Request, err error) {
+		reported = append(reported, err)
+	}))
+	router.GET("/api/pets").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":42,"name":"Rex"}]`))
+	})
+	req := httptest.NewRequest("GET", "/api/pets", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != `[{"id":42,"name":"Rex"}]` {
+		t.Errorf("response: got %d '%s'", rec.Code, rec.Body.String())
+	}
+	if len(reported) != 1 || reported[0].Error() != "body '/0/id': must be string" {
+		t.Errorf("reported: got %v", reported)
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{"json", `{"openapi": "3.0.3", "info": {"title": "API", "version": "1"}, "paths": {}}`, true},
+		{"yaml", "openapi: '3.0.3'\ninfo:\n  title: API\n  version: '1'\npaths: {}\n", true},
+		{"swagger", `{"swagger": "2.0"}`, false},
+		{"invalid json", `{"openapi": `, false},
+		{"invalid yaml", "openapi: 3.1.0\n  paths: {}\n", false},
+		{"unsupported yaml", "openapi: 3.1.0\ninfo: &info\n  title: API\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mux.LoadSpec([]byte(tt.data))
+			if tt.ok && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a server-sent event.
+type Event struct {
+	// ID is the event ID, sent back by the client in the Last-Event-ID header when it reconnects.
+	ID string
+	// Event is the event type ("message" for the client if empty).
+	Event string
+	// Data is the event data. A multi-line data is sent as several data lines.
+	Data string
+	// Retry is the reconnection time of the client (not sent if 0).
+	Retry time.Duration
+}
+
+// SSEOptions defines how the event streams are handled.
+type SSEOptions struct {
+	// Heartbeat is the interval between the comments sent to keep the connection
+	// alive (no heartbeat if 0).
+	Heartbeat time.Duration
+	// Retry is the reconnection time sent to the client at the start of the stream
+	// (not sent if 0).
+	Retry time.Duration
+	// Report is called with the error returned by the handler, once the stream
+	// has ended (e.g. to log it). The context.Canceled errors are not reported.
+	Report func(*http.Request, error)
+}
+
+// DefaultSSEOptions are the options of the routes using ThenSSE.
+var DefaultSSEOptions = SSEOptions{
+	Heartbeat: 15 * time.Second,
+}
+
+// EventStream is a stream of server-sent events (text/event-stream).
+// Its methods are safe for concurrent use.
+type EventStream struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	r    *http.Request
+	mu   sync.Mutex
+	done bool // the handler has returned, guarded by mu
+}
+
+// ThenSSE sets the final handler for a route streaming server-sent events,
+// with DefaultSSEOptions.
+//
+// The response headers are sent before fn is called, and every event is flushed.
+// The context is canceled when the client disconnects. An error returned by fn
+// ends the stream: the response is already started, so the error is only passed
+// to the Report function of the options, if any. The errors to render must be
+// detected before, e.g. by the middlewares.
+//
+// Example:
+//
+//	router.GET("/events").ThenSSE(func(ctx context.Context, stream *mux.EventStream) error {
+//		for update := range updates(ctx, stream.LastEventID()) {
+//			if err := stream.Send(mux.Event{ID: update.ID, Data: update.Text}); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	})
+func (r *route) ThenSSE(fn func(context.Context, *EventStream) error) error {
+	return r.ThenSSEWith(DefaultSSEOptions, fn)
+}
+
+// ThenSSEWith is like ThenSSE, with the given options.
+func (r *route) ThenSSEWith(options SSEOptions, fn func(context.Context, *EventStream) error) error {
+	return r.ThenFunc(func(w http.ResponseWriter, req *http.Request) {
+		rc := http.NewResponseController(w)
+		// The stream is not limited by the write timeout of the server.
+		rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusOK)
+		stream := &EventStream{w: w, rc: rc, r: req}
+		if options.Retry > 0 {
+			if err := stream.write(fmt.Sprintf("retry: %d\n\n", options.Retry.Milliseconds())); err != nil {
+				return
+			}
+		} else if err := stream.flush(); err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(req.Context())
+		var wg sync.WaitGroup
+		if options.Heartbeat > 0 {
+			wg.Go(func() { stream.heartbeat(ctx, options.Heartbeat) })
+		}
+		err := fn(ctx, stream)
+
+		cancel()
+		wg.Wait()
+		// The response writer must not be used after the handler returns.
+		stream.mu.Lock()
+		stream.done = true
+		stream.mu.Unlock()
+
+		if err != nil && !errors.Is(err, context.Canceled) && options.Report != nil {
+			options.Report(req, err)
+		}
+	})
+}
+
+// Request returns the request of the stream.
+func (s *EventStream) Request() *http.Request {
+	return s.r
+}
+
+// LastEventID returns the ID of the last event received by the client before
+// it reconnected (Last-Event-ID header), or an empty string.
+func (s *EventStream) LastEventID() string {
+	return s.r.Header.Get("Last-Event-ID")
+}
+
+// Send sends the event, and flushes it.
+func (s *EventStream) Send(e Event) error {
+	if strings.ContainsAny(e.ID, "\r\n\x00") || strings.ContainsAny(e.Event, "\r\n") {
+		return errors.New("sse: invalid event ID or type")
+	}
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	data := strings.ReplaceAll(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// SendJSON sends an event of the given type (if not empty) with v encoded in JSON as data.
+func (s *EventStream) SendJSON(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(Event{Event: event, Data: string(data)})
+}
+
+// Comment sends a comment, ignored by the client (e.g. to keep the connection alive).
+func (s *EventStream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(&b, ": %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// write writes the text to the stream, and flushes it.
+func (s *EventStream) write(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return errors.New("sse: stream closed")
+	}
+	if err := s.r.Context().Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(text)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *EventStream) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rc.Flush()
+}
+
+// heartbeat sends a comment at every interval, until the context is canceled.
+func (s *EventStream) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package mux_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/middleware"
+	"github.com/carlito767/go-stack/mux"
+)
+
+// readEvent reads the lines of the next event (or comment) of the stream.
+func readEvent(t *testing.T, br *bufio.Reader) string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read error: %v (%q)", err, lines)
+		}
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	stopped := make(chan error, 1)
+	router := mux.NewRouter()
+	router.Use(middleware.NewLogger(middleware.FakeTimeProvider{}, false))
+	router.GET("/events").ThenSSEWith(mux.SSEOptions{Heartbeat: 10 * time.Millisecond, Retry: 3 * time.Second}, func(ctx context.Context, stream *mux.EventStream) error {
+		if err := stream.Send(mux.Event{ID: "1", Event: "resume", Data: stream.LastEventID()}); err != nil {
+			return err
+		}
+		if err := stream.Send(mux.Event{ID: "2", Data: "line 1\nline 2"}); err != nil {
+			return err
+		}
+		if err := stream.SendJSON("update", map[string]int{"count": 3}); err != nil {
+			return err
+		}
+		if err := stream.Send(mux.Event{ID: "bad\nid"}); err == nil {
+			t.Error("error expected for an invalid event ID")
+		}
+		<-ctx.Done()
+		stopped <- stream.Send(mux.Event{Data: "too late"})
+		return nil
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"Content-Type": "text/event-stream", "Cache-Control": "no-cache"} {
+		if got := res.Header.Get(key); got != want {
+			t.Errorf("header %s expected: %q, got: %q", key, want, got)
+		}
+	}
+
+	br := bufio.NewReader(res.Body)
+	for _, want := range []string{
+		"retry: 3000\n",
+		"id: 1\nevent: resume\ndata: 41\n",
+		"id: 2\ndata: line 1\ndata: line 2\n",
+		"event: update\ndata: {\"count\":3}\n",
+		": heartbeat\n",
+	} {
+		if got := readEvent(t, br); got != want {
+			t.Errorf("event expected: %q, got: %q", want, got)
+		}
+	}
+
+	// the handler stops when the client disconnects
+	res.Body.Close()
+	select {
+	case err := <-stopped:
+		if err == nil {
+			t.Error("error expected after the client disconnected")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not stopped after the client disconnected")
+	}
+}
+
+func TestSSEReport(t *testing.T) {
+	errStream := errors.New("stream failed")
+	reported := make(chan error, 2)
+	report := func(r *http.Request, err error) { reported <- err }
+
+	router := mux.NewRouter()
+	router.GET("/fail").ThenSSEWith(mux.SSEOptions{Report: report}, func(ctx context.Context, stream *mux.EventStream) error {
+		return errStream
+	})
+	router.GET("/canceled").ThenSSEWith(mux.SSEOptions{Report: report}, func(ctx context.Context, stream *mux.EventStream) error {
+		return context.Canceled
+	})
+	router.GET("/ok").ThenSSEWith(mux.SSEOptions{Report: report}, func(ctx context.Context, stream *mux.EventStream) error {
+		return nil
+	})
+
+	for _, path := range []string{"/fail", "/canceled", "/ok"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	close(reported)
+	var errs []error
+	for err := range reported {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] != errStream {
+		t.Errorf("reported errors expected: [%v], got: %v", errStream, errs)
+	}
+}
@@ -0,0 +1,231 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions defines how the files are served by Static.
+type StaticOptions struct {
+	// Index is the file served for a directory ("index.html" by default).
+	Index string
+	// Browse enables the listing of the directories without index file.
+	Browse bool
+	// Precompressed enables the serving of the precompressed files (.br, .gz),
+	// when they exist and the client accepts their encoding.
+	Precompressed bool
+	// SPA enables the serving of the index file of the root directory
+	// for the unknown paths without extension (e.g. /users/42).
+	SPA bool
+	// SPAExclude lists the request path prefixes without SPA fallback (e.g. "/api/").
+	SPAExclude []string
+}
+
+// Static mounts a file server for the file system under the given prefix.
+// The files are served for the GET and HEAD methods: like for the other routes,
+// the router answers the other methods (see Mux.ServeHTTP).
+//
+// The responses have the ETag (computed from the content) and Last-Modified
+// (if the modification time is known) headers, and the conditional and range
+// requests are supported.
+//
+// Example:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	router.Static("/", assets, mux.StaticOptions{SPA: true, SPAExclude: []string{"/api/"}})
+func (m *Mux) Static(prefix string, fsys fs.FS, options StaticOptions) error {
+	if options.Index == "" {
+		options.Index = "index.html"
+	}
+	return m.mount(http.MethodGet, prefix, &static{fsys: fsys, options: options})
+}
+
+type static struct {
+	fsys    fs.FS
+	options StaticOptions
+	etags   sync.Map // etagKey -> string
+}
+
+type etagKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (s *static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			redirect(w, r, path.Base(r.URL.Path)+"/")
+			return
+		}
+		index := path.Join(name, s.options.Index)
+		if indexInfo, err := fs.Stat(s.fsys, index); err == nil && !indexInfo.IsDir() {
+			s.serveFile(w, r, index, indexInfo)
+			return
+		}
+		if s.options.Browse {
+			s.serveDir(w, r, name)
+			return
+		}
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		if s.fallback(r) {
+			if info, err := fs.Stat(s.fsys, s.options.Index); err == nil && !info.IsDir() {
+				s.serveFile(w, r, s.options.Index, info)
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+	s.serveFile(w, r, name, info)
+}
+
+// fallback reports whether the index file is served for an unknown path.
+func (s *static) fallback(r *http.Request) bool {
+	if !s.options.SPA || path.Ext(r.URL.Path) != "" {
+		return false
+	}
+	requestPath := MountPoint(r) + r.URL.Path
+	for _, prefix := range s.options.SPAExclude {
+		if strings.HasPrefix(requestPath, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// serveFile serves the file, or its precompressed version.
+func (s *static) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if s.options.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+		for _, encoding := range []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if !acceptsEncoding(r, encoding.name) {
+				continue
+			}
+			if compressed, err := fs.Stat(s.fsys, name+encoding.ext); err == nil && !compressed.IsDir() {
+				w.Header().Set("Content-Encoding", encoding.name)
+				name, info = name+encoding.ext, compressed
+				break
+			}
+		}
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// etag returns the entity tag of the file content, computed once per file version.
+func (s *static) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := etagKey{name: name, size: info.Size(), modTime: info.ModTime()}
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+// serveDir serves the listing of the directory.
+func (s *static) serveDir(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<!doctype html>")
+	fmt.Fprintln(w, `<meta name="viewport" content="width=device-width">`)
+	fmt.Fprintln(w, "<pre>")
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		href := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(href.String()), html.EscapeString(name))
+	}
+	fmt.Fprintln(w, "</pre>")
+}
+
+// acceptsEncoding reports whether the client accepts the content encoding.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(coding), encoding) {
+				continue
+			}
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err != nil || v <= 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// redirect redirects the request to the relative path, keeping the query.
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	if q := r.URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
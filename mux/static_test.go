@@ -0,0 +1,122 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestStatic(t *testing.T) {
+	modTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	files := fstest.MapFS{
+		"index.html":       {Data: []byte("<h1>app</h1>"), ModTime: modTime},
+		"css/main.css":     {Data: []byte("body{}"), ModTime: modTime},
+		"js/app.js":        {Data: []byte("plain"), ModTime: modTime},
+		"js/app.js.br":     {Data: []byte("brotli"), ModTime: modTime},
+		"js/app.js.gz":     {Data: []byte("gzip"), ModTime: modTime},
+		"docs/a b.txt":     {Data: []byte("a b")},
+		"docs/sub/c.txt":   {Data: []byte("c")},
+		"embed/index.html": {Data: []byte("embedded")},
+	}
+
+	router := mux.NewRouter()
+	router.GET("/api/status").ThenFunc(reply("ok"))
+	router.DELETE("/api/items/{id}").ThenFunc(reply("deleted"))
+	router.Mount("/admin", http.NotFoundHandler())
+	router.Static("/", files, mux.StaticOptions{Precompressed: true, SPA: true, SPAExclude: []string{"/api/"}})
+	router.Static("/browse", files, mux.StaticOptions{Browse: true})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header http.Header
+		code   int
+		want   string
+		check  map[string]string
+	}{
+		{"file", "GET", "/css/main.css", nil, http.StatusOK, "body{}", map[string]string{
+			"Content-Type":  "text/css; charset=utf-8",
+			"Last-Modified": "Sun, 01 Jan 2023 12:00:00 GMT",
+			"Vary":          "Accept-Encoding",
+		}},
+		{"index", "GET", "/", nil, http.StatusOK, "<h1>app</h1>", map[string]string{"Content-Type": "text/html; charset=utf-8"}},
+		{"api route", "GET", "/api/status", nil, http.StatusOK, "ok", nil},
+		{"spa fallback", "GET", "/users/42", nil, http.StatusOK, "<h1>app</h1>", nil},
+		{"spa excluded", "GET", "/api/unknown", nil, http.StatusNotFound, "404 page not found\n", nil},
+		{"missing file", "GET", "/missing.js", nil, http.StatusNotFound, "404 page not found\n", nil},
+		{"brotli", "GET", "/js/app.js", http.Header{"Accept-Encoding": {"gzip, br"}}, http.StatusOK, "brotli", map[string]string{
+			"Content-Encoding": "br",
+			"Content-Type":     "text/javascript; charset=utf-8",
+		}},
+		{"gzip", "GET", "/js/app.js", http.Header{"Accept-Encoding": {"gzip, br;q=0"}}, http.StatusOK, "gzip", map[string]string{"Content-Encoding": "gzip"}},
+		{"identity", "GET", "/js/app.js", nil, http.StatusOK, "plain", map[string]string{"Content-Encoding": ""}},
+		{"not modified since", "GET", "/css/main.css", http.Header{"If-Modified-Since": {"Sun, 01 Jan 2023 12:00:00 GMT"}}, http.StatusNotModified, "", nil},
+		{"head", "HEAD", "/css/main.css", nil, http.StatusOK, "", nil},
+		{"method not allowed", "POST", "/css/main.css", nil, http.StatusMethodNotAllowed, "Method Not Allowed\n", map[string]string{"Allow": "GET, HEAD, OPTIONS"}},
+		{"options", "OPTIONS", "/css/main.css", nil, http.StatusNoContent, "", map[string]string{"Allow": "GET, HEAD, OPTIONS"}},
+		{"api route options", "OPTIONS", "/api/status", nil, http.StatusNoContent, "", map[string]string{"Allow": "GET, HEAD, OPTIONS"}},
+		{"api route method not allowed", "DELETE", "/api/status", nil, http.StatusMethodNotAllowed, "Method Not Allowed\n", map[string]string{"Allow": "GET, HEAD, OPTIONS"}},
+		{"api route delete", "DELETE", "/api/items/1", nil, http.StatusOK, "deleted", nil},
+		{"api route options with delete", "OPTIONS", "/api/items/1", nil, http.StatusNoContent, "", map[string]string{"Allow": "DELETE, GET, HEAD, OPTIONS"}},
+		{"directory redirect", "GET", "/browse/docs?x=1", nil, http.StatusMovedPermanently, "", map[string]string{"Location": "docs/?x=1"}},
+		{"directory listing", "GET", "/browse/docs/", nil, http.StatusOK, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"a%20b.txt\">a b.txt</a>\n<a href=\"sub/\">sub/</a>\n</pre>\n", nil},
+		{"no directory listing", "GET", "/docs/", nil, http.StatusOK, "<h1>app</h1>", nil},
+		{"directory index", "GET", "/browse/embed/", nil, http.StatusOK, "embedded", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, res.Code)
+			}
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+			for key, want := range tt.check {
+				if got := res.Header().Get(key); got != want {
+					t.Errorf("%s header expected: %q, got: %q", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStaticETag(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.Static("/", os.DirFS(dir), mux.StaticOptions{})
+
+	req := httptest.NewRequest("GET", "/file.txt", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	etag := res.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || len(etag) != 34 {
+		t.Fatalf("invalid ETag: %q", etag)
+	}
+
+	req = httptest.NewRequest("GET", "/file.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified {
+		t.Errorf("status code expected: %d, got: %d", http.StatusNotModified, res.Code)
+	}
+}
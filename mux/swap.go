@@ -0,0 +1,69 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Swap builds a new route table with build, then atomically replaces the routes
+// of the router with it. In-flight requests complete with the previous routes.
+//
+// The router passed to build is the root router, whose routes are replaced:
+// its global middlewares (except those added by the previous build), its NotFound,
+// MethodNotAllowed, InvalidParam and error handlers, and its path options are kept.
+// While building, the route builders return errors (e.g. conflicts) instead of
+// panicking, and the routes are not replaced if any error occurs. The router and
+// its sub-routers must not be modified by other goroutines while building.
+//
+// Example:
+//
+//	err := router.Swap(func(router *mux.Mux) {
+//		router.GET("/status/{code}").ThenFunc(statusHandler)
+//		for _, plugin := range plugins {
+//			router.Mount(plugin.Prefix, plugin.Handler)
+//		}
+//	})
+func (m *Mux) Swap(build func(*Mux)) error {
+	m.tables.mu.Lock()
+	defer m.tables.mu.Unlock()
+
+	current := m.table()
+	root := current.root
+	next := newTable(root)
+	next.notFound = current.notFound
+	next.methodNotAllowed = current.methodNotAllowed
+	next.invalidParam = current.invalidParam
+	next.errorHandler = current.errorHandler
+	next.paths = current.paths
+
+	// the middlewares added while building the current table are replaced
+	// by those added while building the new one
+	previous := root.middlewares
+	root.middlewares = slices.Delete(slices.Clone(previous), current.uses[0], current.uses[1])
+	from := len(root.middlewares)
+	root.build(next, build)
+	if len(next.errs) > 0 {
+		root.middlewares = previous
+		return errors.Join(next.errs...)
+	}
+	next.uses = [2]int{from, len(root.middlewares)}
+	m.tables.live.Store(next)
+	return nil
+}
+
+// build registers the routes of build in the table, instead of the live table:
+// the route builders of the router and of its sub-routers record their errors
+// in the table instead of panicking.
+func (m *Mux) build(t *table, build func(*Mux)) {
+	t.building = true
+	m.tables.staging.Store(t)
+	defer func() {
+		m.tables.staging.Store(nil)
+		t.building = false
+		if v := recover(); v != nil {
+			t.errs = append(t.errs, fmt.Errorf("%v", v))
+		}
+	}()
+	build(m)
+}
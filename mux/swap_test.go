@@ -0,0 +1,214 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func get(router http.Handler, path string) (int, string) {
+	req := httptest.NewRequest("GET", path, nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res.Code, res.Body.String()
+}
+
+func TestSwap(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+	router.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom not found", http.StatusNotFound)
+	}))
+	router.GET("/old").ThenFunc(reply("old"))
+
+	err := router.Swap(func(router *mux.Mux) {
+		router.GET("/new").ThenFunc(reply("new"))
+		router.GET("/named/{id}").Name("named").ThenFunc(reply("named"))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, body := get(router, "/new"); body != "new" {
+		t.Errorf("GET /new = %q; want %q", body, "new")
+	}
+	if code, body := get(router, "/old"); code != http.StatusNotFound || body != "custom not found\n" {
+		t.Errorf("GET /old = %d %q; want %d %q", code, body, http.StatusNotFound, "custom not found\n")
+	}
+	if routes := router.Routes(); len(routes) != 2 || routes[0].Pattern != "/new" {
+		t.Errorf("unexpected routes: %+v", routes)
+	}
+	if path, err := router.URL("named", "id", "1"); err != nil || path != "/named/1" {
+		t.Errorf("unexpected URL: %q (%v)", path, err)
+	}
+
+	// the global middlewares are kept
+	req := httptest.NewRequest("GET", "/new", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Header().Get("X-Trace"); got != "global" {
+		t.Errorf("trace expected: %q, got: %q", "global", got)
+	}
+}
+
+func TestSwapConflicts(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/old").ThenFunc(reply("old"))
+
+	var errs []error
+	err := router.Swap(func(router *mux.Mux) {
+		errs = append(errs, router.GET("/a/{x}").ThenFunc(reply("a")))
+		errs = append(errs, router.GET("/{y}/b").ThenFunc(reply("b")))
+		errs = append(errs, router.GET("/a/{x}").ThenFunc(reply("a")))
+		errs = append(errs, router.GET("invalid").ThenFunc(reply("invalid")))
+		router.Host("{")
+	})
+	if err == nil {
+		t.Fatalf("error expected")
+	}
+	if errs[0] != nil {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	for i, want := range []string{
+		"route 'GET /{y}/b': pattern \"GET /{y}/b\"",
+		"route 'GET /a/{x}': route already registered: 'GET /a/{x}'",
+		"route 'GET invalid': path must begin with '/'",
+	} {
+		if errs[i+1] == nil || !strings.HasPrefix(errs[i+1].Error(), want) {
+			t.Errorf("error expected: %q, got: %v", want, errs[i+1])
+		}
+	}
+	if !strings.Contains(err.Error(), "bad wildcard in host: '{'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the routes are not replaced
+	if _, body := get(router, "/old"); body != "old" {
+		t.Errorf("GET /old = %q; want %q", body, "old")
+	}
+}
+
+func TestSwapInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := mux.NewRouter()
+	router.GET("/").ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("old"))
+	})
+
+	var wg sync.WaitGroup
+	var body string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, body = get(router, "/")
+	}()
+
+	<-started
+	if err := router.Swap(func(router *mux.Mux) {
+		router.GET("/").ThenFunc(reply("new"))
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if body != "old" {
+		t.Errorf("in-flight response expected: %q, got: %q", "old", body)
+	}
+	if _, body := get(router, "/"); body != "new" {
+		t.Errorf("GET / = %q; want %q", body, "new")
+	}
+}
+
+func TestSwapUse(t *testing.T) {
+	router := mux.NewRouter()
+	if err := router.Swap(func(router *mux.Mux) {
+		router.GET("/new").ThenFunc(reply("new"))
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router.Use(trace("global"))
+
+	// the fallback handlers are wrapped by the middlewares added after the swap
+	req := httptest.NewRequest("GET", "/missing", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound || res.Header().Get("X-Trace") != "global" {
+		t.Errorf("GET /missing = %d (trace %q); want %d (trace %q)", res.Code, res.Header().Get("X-Trace"), http.StatusNotFound, "global")
+	}
+	if routes := router.Routes(); len(routes) != 1 || routes[0].Router != router {
+		t.Errorf("unexpected routes: %+v", routes)
+	}
+}
+
+func TestSwapUseInBuild(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+	for range 3 {
+		if err := router.Swap(func(router *mux.Mux) {
+			router.Use(trace("mw"))
+			router.GET("/x").ThenFunc(reply("x"))
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the middlewares added by the previous builds are not applied
+	for _, path := range []string{"/x", "/missing"} {
+		req := httptest.NewRequest("GET", path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if got := strings.Join(res.Header().Values("X-Trace"), ","); got != "global,mw" {
+			t.Errorf("GET %s: trace expected: %q, got: %q", path, "global,mw", got)
+		}
+	}
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	router := mux.NewRouter()
+	router.GET("/").ThenFunc(reply("root"))
+
+	started, done := make(chan struct{}), make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			if i == 1 {
+				close(started)
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, method := range []string{"GET", "OPTIONS", "DELETE"} {
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/routes/1", nil))
+			}
+		}
+	}()
+
+	<-started
+	for i := range 50 {
+		router.GET(fmt.Sprintf("/routes/%d", i)).ThenFunc(reply("get"))
+		router.Handle("PUT", fmt.Sprintf("/routes/%d", i)).Use(trace("put")).ThenFunc(reply("put"))
+	}
+	close(done)
+	wg.Wait()
+
+	if routes := router.Routes(); len(routes) != 101 {
+		t.Errorf("routes expected: %d, got: %d", 101, len(routes))
+	}
+	if _, body := get(router, "/routes/49"); body != "get" {
+		t.Errorf("GET /routes/49 = %q; want %q", body, "get")
+	}
+}
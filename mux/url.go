@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// URL builds the path of the named route, replacing its wildcards with the given
+// parameters, as key/value pairs. The sub-router prefix is included.
+//
+// Wildcards follow the http.ServeMux syntax:
+//
+//	{name}     // a single path segment (escaped)
+//	{name...}  // the remainder of the path (slashes are preserved)
+//	{$}        // the end of the path
+//
+// Example:
+//
+//	router.GET("/status/{code}").Name("status").ThenFunc(statusHandler)
+//	path, err := router.URL("status", "code", "404") // "/status/404"
+func (m *Mux) URL(name string, pairs ...string) (string, error) {
+	r, found := m.table().names[name]
+	if !found {
+		return "", fmt.Errorf("unknown route: '%s'", name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("odd number of parameters for route '%s'", name)
+	}
+
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		if _, found := params[pairs[i]]; found {
+			return "", fmt.Errorf("duplicated parameter for route '%s': '%s'", name, pairs[i])
+		}
+		params[pairs[i]] = pairs[i+1]
+	}
+
+	for key, constraint := range r.constraints {
+		if value, found := params[key]; found {
+			if _, err := parsers[constraint](value); err != nil {
+				return "", fmt.Errorf("route '%s': invalid parameter '%s' (%s): '%s'", name, key, constraint, value)
+			}
+		}
+	}
+
+	path, err := buildPath(r.path, params)
+	if err != nil {
+		return "", fmt.Errorf("route '%s': %w", name, err)
+	}
+	return path, nil
+}
+
+// buildPath replaces the wildcards of the path with the given parameters.
+// Every wildcard must have a parameter, and every parameter must be used.
+func buildPath(path string, params map[string]string) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool, len(params))
+	for path != "" {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			b.WriteString(path)
+			break
+		}
+		j := strings.IndexByte(path[i:], '}')
+		if j < 0 {
+			return "", fmt.Errorf("bad wildcard in path: '%s'", path[i:])
+		}
+		b.WriteString(path[:i])
+		wildcard := path[i+1 : i+j]
+		path = path[i+j+1:]
+
+		if wildcard == "$" {
+			continue
+		}
+		key, multi := strings.CutSuffix(wildcard, "...")
+		value, found := params[key]
+		if !found {
+			return "", fmt.Errorf("missing parameter: '%s'", key)
+		}
+		used[key] = true
+		if multi {
+			segments := strings.Split(value, "/")
+			for k, segment := range segments {
+				segments[k] = url.PathEscape(segment)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			if value == "" {
+				return "", fmt.Errorf("empty parameter: '%s'", key)
+			}
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		if !used[key] {
+			return "", fmt.Errorf("unexpected parameter: '%s'", key)
+		}
+	}
+	return b.String(), nil
+}
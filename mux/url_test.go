@@ -0,0 +1,145 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func TestURL(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	router := mux.NewRouter()
+	router.GET("/status/{code}").Name("status").Then(h)
+	router.GET("/files/{path...}").Name("files").Then(h)
+	router.GET("/exact/{$}").Name("exact").Then(h)
+	router.GET("/users/{id:int}").Name("user").Then(h)
+	api := router.NewSubRouter("/api/v0")
+	api.GET("/users/{id}/posts/{post}").Name("post").Then(h)
+
+	tests := []struct {
+		name    string
+		route   string
+		pairs   []string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "single wildcard",
+			route: "status",
+			pairs: []string{"code", "404"},
+			want:  "/status/404",
+		},
+		{
+			name:  "escaped value",
+			route: "status",
+			pairs: []string{"code", "a b/c"},
+			want:  "/status/a%20b%2Fc",
+		},
+		{
+			name:  "remainder wildcard",
+			route: "files",
+			pairs: []string{"path", "css/main file.css"},
+			want:  "/files/css/main%20file.css",
+		},
+		{
+			name:  "empty remainder wildcard",
+			route: "files",
+			pairs: []string{"path", ""},
+			want:  "/files/",
+		},
+		{
+			name:  "end wildcard",
+			route: "exact",
+			want:  "/exact/",
+		},
+		{
+			name:  "sub-router prefix",
+			route: "post",
+			pairs: []string{"post", "2", "id", "1"},
+			want:  "/api/v0/users/1/posts/2",
+		},
+		{
+			name:  "constraint",
+			route: "user",
+			pairs: []string{"id", "42"},
+			want:  "/users/42",
+		},
+		{
+			name:    "invalid constraint",
+			route:   "user",
+			pairs:   []string{"id", "abc"},
+			wantErr: errors.New("route 'user': invalid parameter 'id' (int): 'abc'"),
+		},
+		{
+			name:    "unknown route",
+			route:   "unknown",
+			wantErr: errors.New("unknown route: 'unknown'"),
+		},
+		{
+			name:    "odd number of parameters",
+			route:   "status",
+			pairs:   []string{"code"},
+			wantErr: errors.New("odd number of parameters for route 'status'"),
+		},
+		{
+			name:    "duplicated parameter",
+			route:   "status",
+			pairs:   []string{"code", "1", "code", "2"},
+			wantErr: errors.New("duplicated parameter for route 'status': 'code'"),
+		},
+		{
+			name:    "missing parameter",
+			route:   "post",
+			pairs:   []string{"id", "1"},
+			wantErr: errors.New("route 'post': missing parameter: 'post'"),
+		},
+		{
+			name:    "empty parameter",
+			route:   "status",
+			pairs:   []string{"code", ""},
+			wantErr: errors.New("route 'status': empty parameter: 'code'"),
+		},
+		{
+			name:    "unexpected parameter",
+			route:   "status",
+			pairs:   []string{"code", "404", "foo", "bar"},
+			wantErr: errors.New("route 'status': unexpected parameter: 'foo'"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := router.URL(tt.route, tt.pairs...)
+
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantErr.Error() {
+				t.Errorf("error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("url expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDuplicatedRouteName(t *testing.T) {
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	defer func() {
+		want := "route name already registered: 'home'"
+		if r := recover(); r == nil {
+			t.Errorf("the code did not panic")
+		} else if msg := r.(string); msg != want {
+			t.Errorf("panic message expected: '%s', got: '%s'", want, msg)
+		}
+	}()
+
+	router := mux.NewRouter()
+	router.GET("/").Name("home").Then(h)
+	router.NewSubRouter("/sub").GET("/").Name("home").Then(h)
+}
@@ -0,0 +1,173 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VersionOptions defines how the version of a request is selected.
+// The selectors are tried in order: path, media type, header, query.
+type VersionOptions struct {
+	// Path selects the version with the first path segment after the prefix (e.g. /api/v2/users).
+	// The segment is registered as the {version} wildcard.
+	Path bool
+	// Vendor selects the version with the vendor media types of the Accept header
+	// (e.g. "application/vnd.<vendor>.v2+json").
+	Vendor string
+	// Header selects the version with the value of the given header (e.g. "X-Api-Version").
+	Header string
+	// Query selects the version with the value of the given query parameter (e.g. "version").
+	Query string
+	// Default is the version of the requests without version (the latest version by default).
+	// It is not used with the Path selector.
+	Default string
+}
+
+// Versions is a set of API versions sharing the same prefix.
+//
+// A request is served by the route of its version or, if its version doesn't define
+// the route, by the route of the latest older version defining it.
+type Versions struct {
+	m          *Mux
+	options    VersionOptions
+	names      []string
+	versions   []*Mux
+	deprecated []deprecation
+}
+
+type deprecation struct {
+	date   time.Time
+	sunset time.Time
+}
+
+// version identifies the version of a versioned router.
+type version struct {
+	set   *Versions
+	index int
+}
+
+// Versions creates a new set of API versions with the given prefix.
+//
+// Example:
+//
+//	api := router.Versions("/api", mux.VersionOptions{Path: true})
+//	v1 := api.Version("v1")
+//	v1.GET("/users").Then(usersHandler)  // GET /api/v1/users, GET /api/v2/users
+//	v1.GET("/items").Then(itemsHandler)  // GET /api/v1/items
+//	v2 := api.Version("v2")
+//	v2.GET("/items").Then(itemsHandlerV2) // GET /api/v2/items
+//	api.Deprecate("v1", deprecationDate, sunsetDate)
+func (m *Mux) Versions(prefix string, options VersionOptions) *Versions {
+	if !options.Path && options.Vendor == "" && options.Header == "" && options.Query == "" {
+		panic("missing version selector")
+	}
+	return &Versions{m: m.NewSubRouter(prefix), options: options}
+}
+
+// Version creates a new sub-router for the given version.
+// Versions must be created from the oldest to the latest.
+func (vs *Versions) Version(name string) *Mux {
+	if name == "" || slices.Contains(vs.names, name) {
+		panic(fmt.Sprintf("invalid version: '%s'", name))
+	}
+	prefix := ""
+	if vs.options.Path {
+		prefix = "/{version}"
+	}
+	sub := vs.m.NewSubRouter(prefix)
+	v := &version{set: vs, index: len(vs.versions)}
+	sub.version = v
+	sub.matchers = append(sub.matchers, matcher{v.match, http.StatusNotFound, fmt.Sprintf("version %p %s", vs, name)})
+	sub.Use(vs.deprecate)
+
+	vs.names = append(vs.names, name)
+	vs.versions = append(vs.versions, sub)
+	vs.deprecated = append(vs.deprecated, deprecation{})
+	return sub
+}
+
+// Deprecate marks a version as deprecated since the given date.
+// The responses to the requests of the version have the Deprecation header (RFC 9745)
+// and, if the sunset date is not zero, the Sunset header (RFC 8594).
+func (vs *Versions) Deprecate(name string, date time.Time, sunset time.Time) *Versions {
+	i := slices.Index(vs.names, name)
+	if i < 0 {
+		panic(fmt.Sprintf("unknown version: '%s'", name))
+	}
+	vs.deprecated[i] = deprecation{date: date, sunset: sunset}
+	return vs
+}
+
+// resolve returns the index of the version of the request, or -1.
+func (vs *Versions) resolve(r *http.Request) int {
+	name := ""
+	if vs.options.Path {
+		name = r.PathValue("version")
+	}
+	if name == "" && vs.options.Vendor != "" {
+		prefix := "application/vnd." + strings.ToLower(vs.options.Vendor) + "."
+		for _, mediaRange := range parseAccept(r.Header.Values("Accept")) {
+			if rest, ok := strings.CutPrefix(mediaRange, prefix); ok {
+				name, _, _ = strings.Cut(rest, "+")
+				break
+			}
+		}
+	}
+	if name == "" && vs.options.Header != "" {
+		name = r.Header.Get(vs.options.Header)
+	}
+	if name == "" && vs.options.Query != "" {
+		name = r.URL.Query().Get(vs.options.Query)
+	}
+	if name == "" && !vs.options.Path {
+		if vs.options.Default == "" {
+			return len(vs.names) - 1
+		}
+		name = vs.options.Default
+	}
+	return slices.Index(vs.names, name)
+}
+
+// deprecate is a middleware adding the deprecation headers of the request version.
+func (vs *Versions) deprecate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i := vs.resolve(r); i >= 0 {
+			if d := vs.deprecated[i]; !d.date.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.date.Unix()))
+				if !d.sunset.IsZero() {
+					w.Header().Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// match reports whether the route of the version serves the request:
+// the request version must be the same version, or a newer version
+// without route for the request pattern. Otherwise, the request is dispatched
+// to the less specific patterns (see entry.next).
+func (v *version) match(r *http.Request) bool {
+	j := v.set.resolve(r)
+	if j < v.index {
+		return false
+	}
+	e := v.set.m.table().entries[r.Pattern]
+	if e == nil {
+		return true
+	}
+	for _, rt := range e.list() {
+		if rt.m.version != nil && rt.m.version.set == v.set && rt.m.version.index > v.index && rt.m.version.index <= j {
+			return false
+		}
+	}
+	return true
+}
+
+// name returns the name of the version.
+func (v *version) name() string {
+	return v.set.names[v.index]
+}
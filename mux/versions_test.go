@@ -0,0 +1,212 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+func reply(msg string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(msg))
+	}
+}
+
+func TestVersionsByPath(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+
+	api := router.Versions("/api", mux.VersionOptions{Path: true})
+	v1 := api.Version("v1")
+	v1.Use(trace("v1"))
+	v1.GET("/users").ThenFunc(reply("users v1"))
+	v1.GET("/items").ThenFunc(reply("items v1"))
+	v2 := api.Version("v2")
+	v2.GET("/items").ThenFunc(reply("items v2"))
+	v2.DELETE("/items/{id}").ThenFunc(reply("delete v2"))
+	v3 := api.Version("v3")
+	v3.GET("/status").ThenFunc(reply("status v3"))
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		want   string
+		trace  string
+	}{
+		{"GET", "/api/v1/users", http.StatusOK, "users v1", "global"},
+		{"GET", "/api/v2/users", http.StatusOK, "users v1", "global"},
+		{"GET", "/api/v3/users", http.StatusOK, "users v1", "global"},
+		{"GET", "/api/v1/items", http.StatusOK, "items v1", "global"},
+		{"GET", "/api/v2/items", http.StatusOK, "items v2", "global"},
+		{"GET", "/api/v3/items", http.StatusOK, "items v2", "global"},
+		{"GET", "/api/v3/status", http.StatusOK, "status v3", "global"},
+		{"GET", "/api/v2/status", http.StatusNotFound, "404 page not found\n", "global"},
+		{"GET", "/api/v4/users", http.StatusNotFound, "404 page not found\n", "global"},
+		{"DELETE", "/api/v3/items/1", http.StatusOK, "delete v2", "global"},
+		{"DELETE", "/api/v1/items/1", http.StatusNotFound, "404 page not found\n", "global"},
+		{"POST", "/api/v1/items", http.StatusMethodNotAllowed, "Method Not Allowed\n", "global"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != tt.code {
+			t.Errorf("%s %s: status code expected: %d, got: %d", tt.method, tt.path, tt.code, res.Code)
+		}
+		if got := res.Body.String(); got != tt.want {
+			t.Errorf("%s %s = %q; want %q", tt.method, tt.path, got, tt.want)
+		}
+		if got := res.Header().Get("X-Trace"); got != tt.trace {
+			t.Errorf("%s %s: trace expected: %q, got: %q", tt.method, tt.path, tt.trace, got)
+		}
+	}
+
+	if got := router.Routes()[0]; got.Version != "v1" || got.Pattern != "/api/{version}/users" {
+		t.Errorf("unexpected route: %+v", got)
+	}
+}
+
+func TestVersionsFallThrough(t *testing.T) {
+	router := mux.NewRouter()
+
+	api := router.Versions("/api", mux.VersionOptions{Path: true})
+	api.Version("v1").GET("/users/{id}").ThenFunc(reply("user v1"))
+	api.Version("v2").GET("/users/me").ThenFunc(reply("me v2"))
+
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/api/v1/users/me", http.StatusOK, "user v1"},
+		{"/api/v1/users/42", http.StatusOK, "user v1"},
+		{"/api/v2/users/me", http.StatusOK, "me v2"},
+		{"/api/v2/users/42", http.StatusOK, "user v1"},
+		{"/api/v3/users/me", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		if code, body := get(router, tt.path); code != tt.code || body != tt.want {
+			t.Errorf("GET %s = %d %q; want %d %q", tt.path, code, body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestVersionsBySelectors(t *testing.T) {
+	router := mux.NewRouter()
+
+	api := router.Versions("/api", mux.VersionOptions{Vendor: "x", Header: "X-Api-Version", Query: "version"})
+	v1 := api.Version("v1")
+	v1.GET("/users").ThenFunc(reply("users v1"))
+	v2 := api.Version("v2")
+	v2.GET("/users").ThenFunc(reply("users v2"))
+
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		want   string
+	}{
+		{"latest by default", "/api/users", nil, "users v2"},
+		{"vendor media type", "/api/users", http.Header{"Accept": {"application/vnd.x.v1+json"}}, "users v1"},
+		{"header", "/api/users", http.Header{"X-Api-Version": {"v1"}}, "users v1"},
+		{"query", "/api/users?version=v1", nil, "users v1"},
+		{"vendor before query", "/api/users?version=v1", http.Header{"Accept": {"application/vnd.x.v2+json"}}, "users v2"},
+		{"unknown version", "/api/users?version=v3", nil, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if got := res.Body.String(); got != tt.want {
+				t.Errorf("response body expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVersionsDefault(t *testing.T) {
+	router := mux.NewRouter()
+
+	api := router.Versions("/api", mux.VersionOptions{Header: "X-Api-Version", Default: "v1"})
+	api.Version("v1").GET("/users").ThenFunc(reply("users v1"))
+	api.Version("v2").GET("/users").ThenFunc(reply("users v2"))
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got, want := res.Body.String(), "users v1"; got != want {
+		t.Errorf("response body expected: %q, got: %q", want, got)
+	}
+}
+
+func TestVersionsDeprecation(t *testing.T) {
+	router := mux.NewRouter()
+
+	api := router.Versions("/api", mux.VersionOptions{Path: true})
+	api.Version("v1").GET("/users").ThenFunc(reply("users v1"))
+	api.Version("v2")
+	deprecation := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.Deprecate("v1", deprecation, sunset)
+
+	req := httptest.NewRequest("GET", "/api/v1/users", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got, want := res.Header().Get("Deprecation"), "@1672531200"; got != want {
+		t.Errorf("Deprecation header expected: %q, got: %q", want, got)
+	}
+	if got, want := res.Header().Get("Sunset"), "Mon, 01 Jan 2024 00:00:00 GMT"; got != want {
+		t.Errorf("Sunset header expected: %q, got: %q", want, got)
+	}
+
+	// the fallback route of a newer version is not deprecated
+	req = httptest.NewRequest("GET", "/api/v2/users", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if got := res.Header().Get("Deprecation"); got != "" {
+		t.Errorf("unexpected Deprecation header: %q", got)
+	}
+}
+
+func TestVersionsPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+		msg  string
+	}{
+		{"missing selector", func() { mux.NewRouter().Versions("/api", mux.VersionOptions{}) }, "missing version selector"},
+		{"duplicated version", func() {
+			api := mux.NewRouter().Versions("/api", mux.VersionOptions{Path: true})
+			api.Version("v1")
+			api.Version("v1")
+		}, "invalid version: 'v1'"},
+		{"unknown version", func() {
+			api := mux.NewRouter().Versions("/api", mux.VersionOptions{Path: true})
+			api.Deprecate("v1", time.Now(), time.Time{})
+		}, "unknown version: 'v1'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("the code did not panic")
+				} else if msg := r.(string); msg != tt.msg {
+					t.Errorf("panic message expected: '%s', got: '%s'", tt.msg, msg)
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
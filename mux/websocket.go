@@ -0,0 +1,590 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a WebSocket message.
+type MessageType int
+
+// The types of the WebSocket data messages.
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// The status codes of the WebSocket close frames (RFC 6455, section 7.4.1).
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// The opcodes of the WebSocket frames.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// websocketGUID is the GUID of the Sec-WebSocket-Accept computation (RFC 6455, section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrWebSocketClosed is returned by the writes after the close frame is sent.
+var ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+// CloseError is the error returned by the reads of a closed WebSocket connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Reason)
+}
+
+// WebSocketOptions defines how the WebSocket connections are handled.
+type WebSocketOptions struct {
+	// Subprotocols are the supported subprotocols, by order of preference.
+	Subprotocols []string
+	// CheckOrigin reports whether the Origin header of the request is allowed.
+	// By default, the requests with an Origin header are only allowed from the same host.
+	CheckOrigin func(*http.Request) bool
+	// MaxMessageSize is the maximum size of a received message, in bytes (no limit if 0).
+	MaxMessageSize int64
+	// FragmentSize is the maximum size of the frames of a sent message (no fragmentation if 0).
+	FragmentSize int
+	// PingInterval is the interval between the pings sent to the client (no ping if 0).
+	// The connection is closed if nothing is received during two intervals.
+	PingInterval time.Duration
+	// WriteTimeout is the maximum duration of a write (no timeout if 0).
+	WriteTimeout time.Duration
+}
+
+// DefaultWebSocketOptions are the options of the handlers returned by Upgrade.
+var DefaultWebSocketOptions = WebSocketOptions{
+	MaxMessageSize: 1 << 20, // 1 MB
+	WriteTimeout:   10 * time.Second,
+}
+
+// WebSocket is a WebSocket connection (RFC 6455).
+//
+// A connection supports one concurrent reader and multiple concurrent writers.
+// The ping and close frames of the client are answered while reading.
+type WebSocket struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	r           *http.Request
+	subprotocol string
+	options     WebSocketOptions
+	cancel      context.CancelFunc
+
+	writeMu sync.Mutex
+	sent    bool // close frame sent, guarded by writeMu
+	closeMu sync.Mutex
+	closed  *CloseError
+
+	hubsMu sync.Mutex
+	hubs   map[*Hub]map[string]bool
+}
+
+// WebSocket registers a GET route for WebSocket connections (see Upgrade).
+// The route is not listed in the OpenAPI document.
+//
+// Example:
+//
+//	hub := mux.NewHub()
+//	router.WebSocket("/ws/{room}").Use(auth).Then(mux.Upgrade(func(ctx context.Context, ws *mux.WebSocket) error {
+//		room := ws.Request().PathValue("room")
+//		hub.Join(room, ws)
+//		for {
+//			var msg Message
+//			if err := ws.ReadJSON(&msg); err != nil {
+//				return err
+//			}
+//			hub.BroadcastJSON(room, msg)
+//		}
+//	}))
+func (m *Mux) WebSocket(p string) *route {
+	r := m.GET(p)
+	r.spec.hidden = true
+	return r
+}
+
+// Upgrade returns a handler upgrading the requests to WebSocket connections,
+// and calling fn with DefaultWebSocketOptions.
+//
+// The context is canceled when the connection is closed. When fn returns,
+// the connection is closed with the CloseNormal status code (or CloseInternalError
+// if fn returned an error other than a CloseError).
+func Upgrade(fn func(context.Context, *WebSocket) error) http.Handler {
+	return UpgradeWith(DefaultWebSocketOptions, fn)
+}
+
+// UpgradeWith is like Upgrade, with the given options.
+func UpgradeWith(options WebSocketOptions, fn func(context.Context, *WebSocket) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+			w.Header().Set("Upgrade", "websocket")
+			http.Error(w, "websocket: upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			http.Error(w, "websocket: unsupported version", http.StatusUpgradeRequired)
+			return
+		}
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if nonce, err := base64.StdEncoding.DecodeString(key); err != nil || len(nonce) != 16 {
+			http.Error(w, "websocket: invalid key", http.StatusBadRequest)
+			return
+		}
+		checkOrigin := options.CheckOrigin
+		if checkOrigin == nil {
+			checkOrigin = sameOrigin
+		}
+		if !checkOrigin(r) {
+			http.Error(w, "websocket: origin not allowed", http.StatusForbidden)
+			return
+		}
+		subprotocol := selectSubprotocol(r, options.Subprotocols)
+
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, "websocket: hijacking not supported", http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		h := sha1.Sum([]byte(key + websocketGUID))
+		header := w.Header().Clone()
+		header.Set("Upgrade", "websocket")
+		header.Set("Connection", "Upgrade")
+		header.Set("Sec-WebSocket-Accept", base64.StdEncoding.EncodeToString(h[:]))
+		if subprotocol != "" {
+			header.Set("Sec-WebSocket-Protocol", subprotocol)
+		}
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		header.Write(brw)
+		brw.WriteString("\r\n")
+		if err := brw.Flush(); err != nil {
+			return
+		}
+		conn.SetDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		ws := &WebSocket{conn: conn, br: brw.Reader, r: r, subprotocol: subprotocol, options: options, cancel: cancel}
+		if options.PingInterval > 0 {
+			go ws.ping(ctx)
+		}
+
+		err = fn(ctx, ws)
+		ws.leaveHubs()
+		code, reason := CloseNormal, ""
+		var closeErr *CloseError
+		switch {
+		case errors.As(err, &closeErr):
+			code, reason = closeErr.Code, closeErr.Reason
+		case err != nil:
+			code = CloseInternalError
+		}
+		ws.finish(code, reason)
+	})
+}
+
+// Request returns the upgraded request (e.g. for its path parameters).
+func (ws *WebSocket) Request() *http.Request {
+	return ws.r
+}
+
+// Subprotocol returns the negotiated subprotocol, or an empty string.
+func (ws *WebSocket) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// ReadMessage reads the next data message, answering the control frames received before it.
+// The error is a *CloseError when the connection is closed by the client (or by a protocol error).
+func (ws *WebSocket) ReadMessage() (MessageType, []byte, error) {
+	var typ MessageType
+	var message []byte
+	for {
+		if ws.options.PingInterval > 0 {
+			ws.conn.SetReadDeadline(time.Now().Add(2 * ws.options.PingInterval))
+		}
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, ws.fail(err)
+		}
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload, true); err != nil {
+				return 0, nil, ws.fail(err)
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, ws.receiveClose(payload)
+		case opText, opBinary:
+			if message != nil {
+				return 0, nil, ws.fail(&CloseError{CloseProtocolError, "unexpected data frame"})
+			}
+			typ, message = MessageType(opcode), []byte{}
+		case opContinuation:
+			if message == nil {
+				return 0, nil, ws.fail(&CloseError{CloseProtocolError, "unexpected continuation frame"})
+			}
+		}
+
+		if max := ws.options.MaxMessageSize; max > 0 && int64(len(message)+len(payload)) > max {
+			return 0, nil, ws.fail(&CloseError{CloseMessageTooBig, "message too big"})
+		}
+		message = append(message, payload...)
+		if fin {
+			if typ == TextMessage && !utf8.Valid(message) {
+				return 0, nil, ws.fail(&CloseError{CloseInvalidPayload, "invalid UTF-8"})
+			}
+			return typ, message, nil
+		}
+	}
+}
+
+// ReadJSON reads the next data message and decodes it into v.
+func (ws *WebSocket) ReadJSON(v any) error {
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(message, v)
+}
+
+// WriteMessage writes a data message, in fragments if it is larger than the fragment size.
+func (ws *WebSocket) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type: %d", typ)
+	}
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	opcode := byte(typ)
+	size := ws.options.FragmentSize
+	for {
+		fragment := data
+		if size > 0 && len(fragment) > size {
+			fragment = fragment[:size]
+		}
+		data = data[len(fragment):]
+		if err := ws.writeFrameLocked(opcode, fragment, len(data) == 0); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		opcode = opContinuation
+	}
+}
+
+// WriteJSON writes v encoded in JSON as a text message.
+func (ws *WebSocket) WriteJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, b)
+}
+
+// Ping sends a ping frame (at most 125 bytes of data).
+func (ws *WebSocket) Ping(data []byte) error {
+	return ws.writeFrame(opPing, data, true)
+}
+
+// Close starts the close handshake, with the given status code and reason.
+// The reader receives a *CloseError when the client answers.
+func (ws *WebSocket) Close(code int, reason string) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.sent {
+		return nil
+	}
+	err := ws.writeFrameLocked(opClose, closePayload(code, reason), true)
+	ws.sent = true
+	return err
+}
+
+// readFrame reads a frame of the client.
+func (ws *WebSocket) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &CloseError{CloseProtocolError, "reserved bits set"}
+	}
+	switch opcode {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return false, 0, nil, &CloseError{CloseProtocolError, "unknown opcode"}
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, &CloseError{CloseProtocolError, "unmasked frame"}
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(ws.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(ws.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(b[:])
+		if length>>63 != 0 {
+			return false, 0, nil, &CloseError{CloseProtocolError, "invalid length"}
+		}
+	}
+	if opcode >= opClose && (length > 125 || !fin) {
+		return false, 0, nil, &CloseError{CloseProtocolError, "invalid control frame"}
+	}
+	if max := ws.options.MaxMessageSize; max > 0 && length > uint64(max) {
+		return false, 0, nil, &CloseError{CloseMessageTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a frame to the client.
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte, fin bool) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return ws.writeFrameLocked(opcode, payload, fin)
+}
+
+func (ws *WebSocket) writeFrameLocked(opcode byte, payload []byte, fin bool) error {
+	if ws.sent {
+		return ErrWebSocketClosed
+	}
+	if opcode >= opClose && len(payload) > 125 {
+		return errors.New("websocket: control frame too long")
+	}
+	frame := make([]byte, 0, 10+len(payload))
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	frame = append(frame, b0)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+
+	if ws.options.WriteTimeout > 0 {
+		ws.conn.SetWriteDeadline(time.Now().Add(ws.options.WriteTimeout))
+	}
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// receiveClose answers the close frame of the client, and returns the close error.
+func (ws *WebSocket) receiveClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		closeErr = &CloseError{CloseProtocolError, "invalid close frame"}
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !validCloseCode(closeErr.Code) || !utf8.ValidString(closeErr.Reason) {
+			closeErr = &CloseError{CloseProtocolError, "invalid close frame"}
+		}
+	}
+
+	code := closeErr.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	ws.Close(code, "")
+	ws.setClosed(closeErr)
+	return closeErr
+}
+
+// fail closes the connection after a read error, with the status code
+// of the error if it is a protocol violation.
+func (ws *WebSocket) fail(err error) error {
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		ws.Close(closeErr.Code, closeErr.Reason)
+	} else {
+		closeErr = &CloseError{Code: CloseAbnormal, Reason: err.Error()}
+	}
+	ws.setClosed(closeErr)
+	ws.conn.Close()
+	return closeErr
+}
+
+func (ws *WebSocket) setClosed(err *CloseError) {
+	ws.closeMu.Lock()
+	if ws.closed == nil {
+		ws.closed = err
+	}
+	ws.closeMu.Unlock()
+	ws.cancel()
+}
+
+// finish completes the close handshake when the handler returns.
+func (ws *WebSocket) finish(code int, reason string) {
+	ws.closeMu.Lock()
+	closed := ws.closed != nil
+	ws.closeMu.Unlock()
+	if closed {
+		return
+	}
+	if err := ws.Close(code, reason); err != nil {
+		return
+	}
+	// Wait for the close frame of the client.
+	ws.conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, opcode, _, err := ws.readFrame()
+		if err != nil || opcode == opClose {
+			return
+		}
+	}
+}
+
+// ping sends a ping frame at every ping interval, until the context is canceled.
+func (ws *WebSocket) ping(ctx context.Context) {
+	ticker := time.NewTicker(ws.options.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ws.Ping(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// closePayload returns the payload of a close frame.
+func closePayload(code int, reason string) []byte {
+	if code == CloseNoStatus || code == CloseAbnormal {
+		return nil
+	}
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// validCloseCode reports whether the status code can be received in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	}
+	return false
+}
+
+// headerContains reports whether the comma-separated header contains the token (case-insensitive).
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectSubprotocol returns the first supported subprotocol requested by the client.
+func selectSubprotocol(r *http.Request, supported []string) string {
+	var requested []string
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, part := range strings.Split(value, ",") {
+			requested = append(requested, strings.TrimSpace(part))
+		}
+	}
+	for _, subprotocol := range supported {
+		if slices.Contains(requested, subprotocol) {
+			return subprotocol
+		}
+	}
+	return ""
+}
+
+// sameOrigin reports whether the request has no Origin header,
+// or an Origin header with the host of the request.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,318 @@
+package mux_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlito767/go-stack/mux"
+)
+
+// wsClient is a minimal WebSocket client, writing raw frames.
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+	res  *http.Response
+}
+
+func dialWS(t *testing.T, server *httptest.Server, path string, header http.Header) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest("GET", server.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{conn: conn, br: br, res: res}
+}
+
+func (c *wsClient) write(t *testing.T, opcode byte, fin bool, payload []byte) {
+	t.Helper()
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *wsClient) read(t *testing.T) (byte, bool, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("masked server frame")
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		io.ReadFull(c.br, b[:])
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(c.br, b[:])
+		length = binary.BigEndian.Uint64(b[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, header[0]&0x80 != 0, payload
+}
+
+func (c *wsClient) expect(t *testing.T, opcode byte, fin bool, payload string) {
+	t.Helper()
+	gotOpcode, gotFin, gotPayload := c.read(t)
+	if gotOpcode != opcode || gotFin != fin || string(gotPayload) != payload {
+		t.Fatalf("frame expected: %x %v %q, got: %x %v %q", opcode, fin, payload, gotOpcode, gotFin, gotPayload)
+	}
+}
+
+func closeFrame(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+type chatMessage struct {
+	Room string `json:"room"`
+	Text string `json:"text"`
+}
+
+func TestWebSocket(t *testing.T) {
+	closed := make(chan error, 1)
+	options := mux.DefaultWebSocketOptions
+	options.Subprotocols = []string{"chat.v2", "chat.v1"}
+	options.MaxMessageSize = 1024
+	options.FragmentSize = 4
+
+	router := mux.NewRouter()
+	router.Use(trace("global"))
+	router.WebSocket("/ws/{room}").Then(mux.UpgradeWith(options, func(ctx context.Context, ws *mux.WebSocket) error {
+		for {
+			typ, message, err := ws.ReadMessage()
+			if err != nil {
+				closed <- err
+				return err
+			}
+			switch {
+			case string(message) == "json":
+				err = ws.WriteJSON(chatMessage{Room: ws.Request().PathValue("room"), Text: ws.Subprotocol()})
+			default:
+				err = ws.WriteMessage(typ, message)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := dialWS(t, server, "/ws/lobby", http.Header{"Sec-Websocket-Protocol": {"chat.v1, chat.v2"}})
+	if c.res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status code expected: %d, got: %d", http.StatusSwitchingProtocols, c.res.StatusCode)
+	}
+	if got := c.res.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("accept expected: %q, got: %q", "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", got)
+	}
+	if got := c.res.Header.Get("Sec-WebSocket-Protocol"); got != "chat.v2" {
+		t.Errorf("subprotocol expected: %q, got: %q", "chat.v2", got)
+	}
+	if got := c.res.Header.Get("X-Trace"); got != "global" {
+		t.Errorf("middleware header expected: %q, got: %q", "global", got)
+	}
+
+	// fragmented message, with a ping between the fragments
+	c.write(t, 0x1, false, []byte("Hel"))
+	c.write(t, 0x9, true, []byte("ping"))
+	c.write(t, 0x0, true, []byte("lo"))
+	c.expect(t, 0xA, true, "ping")
+	c.expect(t, 0x1, false, "Hell")
+	c.expect(t, 0x0, true, "o")
+
+	// JSON helper
+	c.write(t, 0x1, true, []byte("json"))
+	var got []byte
+	for {
+		opcode, fin, payload := c.read(t)
+		if opcode != 0x1 && opcode != 0x0 {
+			t.Fatalf("unexpected opcode: %x", opcode)
+		}
+		got = append(got, payload...)
+		if fin {
+			break
+		}
+	}
+	if want := `{"room":"lobby","text":"chat.v2"}`; string(got) != want {
+		t.Errorf("JSON expected: %s, got: %s", want, got)
+	}
+
+	// close handshake
+	c.write(t, 0x8, true, closeFrame(mux.CloseGoingAway, "bye"))
+	c.expect(t, 0x8, true, string(closeFrame(mux.CloseGoingAway, "")))
+	var closeErr *mux.CloseError
+	if err := <-closed; !errors.As(err, &closeErr) || closeErr.Code != mux.CloseGoingAway || closeErr.Reason != "bye" {
+		t.Errorf("close error expected: 1001 bye, got: %v", err)
+	}
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	options := mux.DefaultWebSocketOptions
+	options.MaxMessageSize = 8
+
+	router := mux.NewRouter()
+	router.WebSocket("/ws").Then(mux.UpgradeWith(options, func(ctx context.Context, ws *mux.WebSocket) error {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return err
+			}
+		}
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		frames func(t *testing.T, c *wsClient)
+		code   int
+	}{
+		{"unmasked frame", func(t *testing.T, c *wsClient) {
+			c.conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+		}, mux.CloseProtocolError},
+		{"continuation without start", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x0, true, []byte("hi"))
+		}, mux.CloseProtocolError},
+		{"data frame during fragmentation", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x1, false, []byte("a"))
+			c.write(t, 0x1, true, []byte("b"))
+		}, mux.CloseProtocolError},
+		{"fragmented control frame", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x9, false, nil)
+		}, mux.CloseProtocolError},
+		{"reserved opcode", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x3, true, nil)
+		}, mux.CloseProtocolError},
+		{"message too big", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x1, false, []byte("12345"))
+			c.write(t, 0x0, true, []byte("67890"))
+		}, mux.CloseMessageTooBig},
+		{"invalid UTF-8", func(t *testing.T, c *wsClient) {
+			c.write(t, 0x1, true, []byte{0xff, 0xfe})
+		}, mux.CloseInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dialWS(t, server, "/ws", nil)
+			tt.frames(t, c)
+			opcode, _, payload := c.read(t)
+			if opcode != 0x8 || len(payload) < 2 {
+				t.Fatalf("close frame expected, got: %x %q", opcode, payload)
+			}
+			if code := int(binary.BigEndian.Uint16(payload)); code != tt.code {
+				t.Errorf("close code expected: %d, got: %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandshakeErrors(t *testing.T) {
+	router := mux.NewRouter()
+	router.WebSocket("/ws").Then(mux.Upgrade(func(ctx context.Context, ws *mux.WebSocket) error {
+		return nil
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		code   int
+	}{
+		{"unsupported version", http.Header{"Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"invalid key", http.Header{"Sec-Websocket-Key": {"short"}}, http.StatusBadRequest},
+		{"cross origin", http.Header{"Origin": {"https://evil.example.com"}}, http.StatusForbidden},
+		{"same origin", http.Header{"Origin": {server.URL}}, http.StatusSwitchingProtocols},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dialWS(t, server, "/ws", tt.header)
+			if c.res.StatusCode != tt.code {
+				t.Errorf("status code expected: %d, got: %d", tt.code, c.res.StatusCode)
+			}
+		})
+	}
+
+	res, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("status code expected: %d, got: %d", http.StatusUpgradeRequired, res.StatusCode)
+	}
+}
+
+func TestWebSocketHandlerError(t *testing.T) {
+	router := mux.NewRouter()
+	router.WebSocket("/ws").Then(mux.Upgrade(func(ctx context.Context, ws *mux.WebSocket) error {
+		if err := ws.WriteMessage(mux.BinaryMessage, []byte{1, 2, 3}); err != nil {
+			return err
+		}
+		return errors.New("database unavailable")
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := dialWS(t, server, "/ws", nil)
+	c.expect(t, 0x2, true, "\x01\x02\x03")
+	c.expect(t, 0x8, true, string(closeFrame(mux.CloseInternalError, "")))
+	c.write(t, 0x8, true, closeFrame(mux.CloseInternalError, ""))
+}